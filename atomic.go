@@ -0,0 +1,268 @@
+package kvstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrAlreadyExists is returned by CreateOnly when the key already has a
+// value, mirroring kvdb's Create semantics.
+var ErrAlreadyExists = errors.New("kvstore: key already exists")
+
+// CompareAndSwap atomically replaces key's value with new, but only if its
+// current value equals old, via a single UPDATE ... WHERE statement that
+// also excludes an expired-but-not-yet-reaped row, same as TryGet. old and
+// new are encoded with the store's configured value codec, matching
+// whatever TrySet wrote. It reports whether the swap happened. On a store
+// with secondary indexes, it instead runs inside a Transaction so Tx.Set
+// keeps them in sync.
+func (s *KV[T1, T2]) CompareAndSwap(key T1, old, new T2) (bool, error) {
+	if s.hasIndexes() {
+		var swapped bool
+		err := s.Transaction(func(tx *Tx[T1, T2]) error {
+			var err error
+			swapped, err = tx.CompareAndSwap(key, old, new)
+			return err
+		})
+		return swapped, err
+	}
+
+	if err := s.requireDefaultBackend("CompareAndSwap"); err != nil {
+		return false, err
+	}
+
+	keyBytes, err := s.kc().Marshal(key)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal key: %w", err)
+	}
+	oldBytes, err := s.vc().Marshal(old)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal old value: %w", err)
+	}
+	newBytes, err := s.vc().Marshal(new)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal new value: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	query := fmt.Sprintf("UPDATE %s SET value = ? WHERE key = ? AND value = ? AND (expiry IS NULL OR expiry > ?)", s.table)
+	result, err := s.db.ExecContext(ctx, query, newBytes, string(keyBytes), oldBytes, time.Now().UnixNano())
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	swapped := rowsAffected > 0
+
+	if swapped && s.broadcaster != nil {
+		event := WatchEvent[T1, T2]{
+			Type:      WatchEventSet,
+			Key:       key,
+			Value:     new,
+			OldValue:  old,
+			Action:    Update,
+			PrevValue: old,
+			Exists:    true,
+		}
+		if rev, histErr := s.appendHistory(key, old, new, Update); histErr == nil {
+			event.Rev = rev
+		}
+		s.broadcaster.publish(keyString(key), event)
+	}
+
+	return swapped, nil
+}
+
+// CreateOnly inserts value for key only if the key is currently absent, via
+// a single INSERT OR IGNORE statement. It returns ErrAlreadyExists if the
+// key already had a value, so callers get a one-liner for the common
+// watch-test-set pattern:
+//
+//	created, err := store.CreateOnly("lock:leader", nodeID)
+//	if errors.Is(err, kvstore.ErrAlreadyExists) {
+//	    // someone else holds it
+//	}
+//
+// value is encoded with the store's configured value codec. A key whose
+// row has expired but hasn't been reaped yet is treated as absent, same as
+// Has/Get. On a store with secondary indexes, it instead runs inside a
+// Transaction so Tx.Set keeps them in sync.
+func (s *KV[T1, T2]) CreateOnly(key T1, value T2) (bool, error) {
+	if s.hasIndexes() {
+		created := false
+		err := s.Transaction(func(tx *Tx[T1, T2]) error {
+			exists, err := tx.Has(key)
+			if err != nil {
+				return err
+			}
+			if exists {
+				return ErrAlreadyExists
+			}
+			if err := tx.Set(key, value); err != nil {
+				return err
+			}
+			created = true
+			return nil
+		})
+		if err != nil {
+			return false, err
+		}
+		return created, nil
+	}
+
+	if err := s.requireDefaultBackend("CreateOnly"); err != nil {
+		return false, err
+	}
+
+	keyBytes, err := s.kc().Marshal(key)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal key: %w", err)
+	}
+	valueBytes, err := s.vc().Marshal(value)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal value: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	sqlTx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+
+	// An expired-but-not-yet-reaped row must not count as "already exists":
+	// Has/Get already filter it out via their own expiry check, so this
+	// keeps INSERT OR IGNORE's notion of "absent" consistent with theirs.
+	deleteExpired := fmt.Sprintf("DELETE FROM %s WHERE key = ? AND expiry IS NOT NULL AND expiry <= ?", s.table)
+	if _, err := sqlTx.ExecContext(ctx, deleteExpired, string(keyBytes), time.Now().UnixNano()); err != nil {
+		sqlTx.Rollback()
+		return false, err
+	}
+
+	query := fmt.Sprintf("INSERT OR IGNORE INTO %s (key, value) VALUES (?, ?)", s.table)
+	result, err := sqlTx.ExecContext(ctx, query, string(keyBytes), valueBytes)
+	if err != nil {
+		sqlTx.Rollback()
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		sqlTx.Rollback()
+		return false, err
+	}
+	if rowsAffected == 0 {
+		sqlTx.Rollback()
+		return false, ErrAlreadyExists
+	}
+
+	if err := sqlTx.Commit(); err != nil {
+		return false, err
+	}
+
+	if s.broadcaster != nil {
+		var zero T2
+		event := WatchEvent[T1, T2]{
+			Type:   WatchEventSet,
+			Key:    key,
+			Value:  value,
+			Action: Create,
+			Exists: false,
+		}
+		if rev, histErr := s.appendHistory(key, zero, value, Create); histErr == nil {
+			event.Rev = rev
+		}
+		s.broadcaster.publish(keyString(key), event)
+	}
+
+	return true, nil
+}
+
+// Increment adds delta to key's current value (treating an absent key as
+// zero) and stores the result, retrying CompareAndSwap/CreateOnly under
+// contention until it wins the race. T2 must be one of Go's built-in
+// integer or floating-point types.
+func (s *KV[T1, T2]) Increment(key T1, delta T2) (T2, error) {
+	var zero T2
+	for {
+		old, err := s.TryGet(key)
+		if err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				return zero, err
+			}
+			newValue, err := addNumeric(zero, delta)
+			if err != nil {
+				return zero, err
+			}
+			created, err := s.CreateOnly(key, newValue)
+			if err != nil {
+				if errors.Is(err, ErrAlreadyExists) {
+					continue // someone else created it first; retry against the real value
+				}
+				return zero, err
+			}
+			if created {
+				return newValue, nil
+			}
+			continue
+		}
+
+		newValue, err := addNumeric(old, delta)
+		if err != nil {
+			return zero, err
+		}
+		swapped, err := s.CompareAndSwap(key, old, newValue)
+		if err != nil {
+			return zero, err
+		}
+		if swapped {
+			return newValue, nil
+		}
+		// Lost the race to a concurrent writer; retry with the fresh value.
+	}
+}
+
+// addNumeric adds two values of the same underlying numeric type. T2 only
+// carries the comparable constraint at the method level, so the concrete
+// arithmetic is resolved with a type switch rather than a Number
+// constraint, which Go does not allow methods to add on top of KV's own
+// type parameters.
+func addNumeric[T2 comparable](a, b T2) (T2, error) {
+	switch av := any(a).(type) {
+	case int:
+		return any(av + any(b).(int)).(T2), nil
+	case int8:
+		return any(av + any(b).(int8)).(T2), nil
+	case int16:
+		return any(av + any(b).(int16)).(T2), nil
+	case int32:
+		return any(av + any(b).(int32)).(T2), nil
+	case int64:
+		return any(av + any(b).(int64)).(T2), nil
+	case uint:
+		return any(av + any(b).(uint)).(T2), nil
+	case uint8:
+		return any(av + any(b).(uint8)).(T2), nil
+	case uint16:
+		return any(av + any(b).(uint16)).(T2), nil
+	case uint32:
+		return any(av + any(b).(uint32)).(T2), nil
+	case uint64:
+		return any(av + any(b).(uint64)).(T2), nil
+	case float32:
+		return any(av + any(b).(float32)).(T2), nil
+	case float64:
+		return any(av + any(b).(float64)).(T2), nil
+	default:
+		var zero T2
+		return zero, fmt.Errorf("kvstore: Increment requires a numeric value type, got %T", a)
+	}
+}
@@ -0,0 +1,165 @@
+package kvstore_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/xtdlib/kvstore"
+)
+
+func TestCompareAndSwap(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_cas.db")
+
+	store, err := kvstore.NewAt[string, string](dbPath, "test_cas")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	store.Set("key1", "value1")
+
+	swapped, err := store.CompareAndSwap("key1", "wrong", "value2")
+	if err != nil {
+		t.Fatalf("CompareAndSwap failed: %v", err)
+	}
+	if swapped {
+		t.Fatal("Expected swap to fail on stale old value")
+	}
+
+	swapped, err = store.CompareAndSwap("key1", "value1", "value2")
+	if err != nil {
+		t.Fatalf("CompareAndSwap failed: %v", err)
+	}
+	if !swapped {
+		t.Fatal("Expected swap to succeed")
+	}
+	if store.Get("key1") != "value2" {
+		t.Fatalf("Expected value2, got %s", store.Get("key1"))
+	}
+}
+
+func TestCreateOnly(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_create_only.db")
+
+	store, err := kvstore.NewAt[string, string](dbPath, "test_create_only")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	created, err := store.CreateOnly("key1", "value1")
+	if err != nil {
+		t.Fatalf("CreateOnly failed: %v", err)
+	}
+	if !created {
+		t.Fatal("Expected key1 to be created")
+	}
+
+	created, err = store.CreateOnly("key1", "value2")
+	if !errors.Is(err, kvstore.ErrAlreadyExists) {
+		t.Fatalf("Expected ErrAlreadyExists, got %v", err)
+	}
+	if created {
+		t.Fatal("Expected created=false for existing key")
+	}
+	if store.Get("key1") != "value1" {
+		t.Fatalf("Expected original value1 preserved, got %s", store.Get("key1"))
+	}
+}
+
+func TestCreateOnlySucceedsOnExpiredButUnreapedKey(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_create_only_expired.db")
+
+	store, err := kvstore.NewAt[string, string](dbPath, "test_create_only_expired")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	// Already in the past, and the background reaper (default 5s interval)
+	// won't have swept it yet by the time CreateOnly runs below.
+	if err := store.SetWithExpiry("key1", "stale", time.Now().Add(-time.Hour)); err != nil {
+		t.Fatalf("SetWithExpiry failed: %v", err)
+	}
+
+	created, err := store.CreateOnly("key1", "fresh")
+	if err != nil {
+		t.Fatalf("Expected CreateOnly to treat an expired row as absent, got err=%v", err)
+	}
+	if !created {
+		t.Fatal("Expected key1 to be (re)created")
+	}
+	if store.Get("key1") != "fresh" {
+		t.Fatalf("Expected fresh, got %s", store.Get("key1"))
+	}
+}
+
+func TestIncrement(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_increment.db")
+
+	store, err := kvstore.NewAt[string, int](dbPath, "test_increment")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	newValue, err := store.Increment("counter", 5)
+	if err != nil {
+		t.Fatalf("Increment failed: %v", err)
+	}
+	if newValue != 5 {
+		t.Fatalf("Expected 5 on first increment, got %d", newValue)
+	}
+
+	newValue, err = store.Increment("counter", 3)
+	if err != nil {
+		t.Fatalf("Increment failed: %v", err)
+	}
+	if newValue != 8 {
+		t.Fatalf("Expected 8 after second increment, got %d", newValue)
+	}
+}
+
+// TestIncrementWithGobCodecValue proves Increment's CompareAndSwap call
+// compares against a gob-encoded old value (via s.vc()) rather than always
+// re-marshaling with encoding/json, which could never match what TrySet
+// wrote and would make Increment spin in its retry loop forever.
+func TestIncrementWithGobCodecValue(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_increment_gob.db")
+
+	store, err := kvstore.NewAtWithCodec[string, int](dbPath, "test_increment_gob", kvstore.JSONCodec{}, kvstore.GobCodec{})
+	if err != nil {
+		t.Fatalf("NewAtWithCodec failed: %v", err)
+	}
+
+	// The first Increment on an absent key goes through CreateOnly, not
+	// CompareAndSwap; the second is the one that would spin forever if
+	// CompareAndSwap's WHERE value = ? compared against a json.Marshal of
+	// old instead of the gob-encoded bytes TrySet/CreateOnly actually wrote.
+	if _, err := store.Increment("counter", 5); err != nil {
+		t.Fatalf("First Increment failed: %v", err)
+	}
+
+	done := make(chan struct{})
+	var newValue int
+	go func() {
+		defer close(done)
+		newValue, err = store.Increment("counter", 3)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("Increment did not return; likely spinning on a codec mismatch in CompareAndSwap")
+	}
+
+	if err != nil {
+		t.Fatalf("Second Increment failed: %v", err)
+	}
+	if newValue != 8 {
+		t.Fatalf("Expected 8, got %d", newValue)
+	}
+}
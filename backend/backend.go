@@ -0,0 +1,40 @@
+// Package backend names the storage interface kvstore's primary
+// key/value table runs on top of, so that table can live on SQLite,
+// bbolt, or anything else that can satisfy Backend.
+package backend
+
+import "context"
+
+// Row is one key's value and optional TTL expiry (unix nanoseconds, nil
+// meaning no expiry) as stored in a Backend's primary table.
+type Row struct {
+	Value  []byte
+	Expiry *int64
+}
+
+// Backend is the storage interface kvstore.KV's primary key/value table
+// runs on top of. It is deliberately scoped to what TrySet/TryGet/TryHas/
+// TryDelete/TryClear need: iteration, revision history, secondary indexes,
+// leases, atomic compare-and-swap, transactions, and range scans still
+// talk to SQLite directly and require the default backend (see
+// backend/sqlite). AddIndex, History/Compact, CompareAndSwap/CreateOnly,
+// Transaction/TxnOps, CountPrefix/Range/RangePrefix, TryForEach/
+// TryForEachReverse/Iter/IterReverse/All/Backward, and
+// Grant/SetWithLease/KeepAlive/Revoke all refuse to run on a store using
+// any other Backend, since those features read/write the primary (or a
+// sibling) table with raw SQL that a non-SQLite Backend's rows never
+// populate.
+type Backend interface {
+	// EnsureTable creates table if it does not already exist.
+	EnsureTable(ctx context.Context, table string) error
+	// Get returns the row stored at key in table. ok is false if the key
+	// has no row, or its row has expired.
+	Get(ctx context.Context, table, key string) (row Row, ok bool, err error)
+	// Put writes (or replaces) the row at key in table.
+	Put(ctx context.Context, table, key string, row Row) error
+	// Delete removes key from table and reports whether it existed.
+	Delete(ctx context.Context, table, key string) (existed bool, err error)
+	// Clear removes every row from table.
+	Clear(ctx context.Context, table string) error
+	Close() error
+}
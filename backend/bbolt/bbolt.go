@@ -0,0 +1,132 @@
+// Package bbolt is an alternative kvstore Backend, backed by
+// go.etcd.io/bbolt instead of SQLite, for the primary key/value table
+// only. See backend.Backend's doc comment for what that does and does not
+// cover.
+package bbolt
+
+import (
+	"context"
+	"encoding/binary"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/xtdlib/kvstore/backend"
+)
+
+// Backend adapts db to backend.Backend. Each kvstore table maps to two
+// bbolt buckets: table itself holds key -> value, and table's expiry
+// sibling bucket holds key -> big-endian unix-nanoseconds expiry for keys
+// that have one.
+type Backend struct {
+	DB *bolt.DB
+}
+
+// New wraps an already-open *bolt.DB.
+func New(db *bolt.DB) *Backend {
+	return &Backend{DB: db}
+}
+
+func expiryBucket(table string) []byte {
+	return []byte(table + "__expiry")
+}
+
+func (b *Backend) EnsureTable(ctx context.Context, table string) error {
+	return b.DB.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists([]byte(table)); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(expiryBucket(table))
+		return err
+	})
+}
+
+func (b *Backend) Get(ctx context.Context, table, key string) (backend.Row, bool, error) {
+	var row backend.Row
+	var found bool
+	err := b.DB.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(table))
+		if bucket == nil {
+			return nil
+		}
+		value := bucket.Get([]byte(key))
+		if value == nil {
+			return nil
+		}
+		if eb := tx.Bucket(expiryBucket(table)); eb != nil {
+			if raw := eb.Get([]byte(key)); raw != nil {
+				expiry := int64(binary.BigEndian.Uint64(raw))
+				if expiry <= time.Now().UnixNano() {
+					return nil
+				}
+				row.Expiry = &expiry
+			}
+		}
+		row.Value = append([]byte(nil), value...)
+		found = true
+		return nil
+	})
+	return row, found, err
+}
+
+func (b *Backend) Put(ctx context.Context, table, key string, row backend.Row) error {
+	return b.DB.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(table))
+		if err != nil {
+			return err
+		}
+		if err := bucket.Put([]byte(key), row.Value); err != nil {
+			return err
+		}
+		eb, err := tx.CreateBucketIfNotExists(expiryBucket(table))
+		if err != nil {
+			return err
+		}
+		if row.Expiry == nil {
+			return eb.Delete([]byte(key))
+		}
+		buf := make([]byte, 8)
+		binary.BigEndian.PutUint64(buf, uint64(*row.Expiry))
+		return eb.Put([]byte(key), buf)
+	})
+}
+
+func (b *Backend) Delete(ctx context.Context, table, key string) (bool, error) {
+	existed := false
+	err := b.DB.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(table))
+		if bucket == nil {
+			return nil
+		}
+		if bucket.Get([]byte(key)) != nil {
+			existed = true
+		}
+		if eb := tx.Bucket(expiryBucket(table)); eb != nil {
+			if err := eb.Delete([]byte(key)); err != nil {
+				return err
+			}
+		}
+		return bucket.Delete([]byte(key))
+	})
+	return existed, err
+}
+
+func (b *Backend) Clear(ctx context.Context, table string) error {
+	return b.DB.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket([]byte(table)); err != nil && err != bolt.ErrBucketNotFound {
+			return err
+		}
+		if err := tx.DeleteBucket(expiryBucket(table)); err != nil && err != bolt.ErrBucketNotFound {
+			return err
+		}
+		if _, err := tx.CreateBucketIfNotExists([]byte(table)); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(expiryBucket(table))
+		return err
+	})
+}
+
+func (b *Backend) Close() error {
+	return b.DB.Close()
+}
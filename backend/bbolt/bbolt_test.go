@@ -0,0 +1,72 @@
+package bbolt_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/xtdlib/kvstore/backend"
+	bboltbackend "github.com/xtdlib/kvstore/backend/bbolt"
+)
+
+func TestBackendSatisfiesBackend(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_bbolt_backend.db")
+
+	db, err := bolt.Open(dbPath, 0600, nil)
+	if err != nil {
+		t.Fatalf("Failed to open db: %v", err)
+	}
+	defer db.Close()
+
+	var b backend.Backend = bboltbackend.New(db)
+	ctx := context.Background()
+
+	if err := b.EnsureTable(ctx, "t"); err != nil {
+		t.Fatalf("EnsureTable failed: %v", err)
+	}
+
+	if err := b.Put(ctx, "t", "a", backend.Row{Value: []byte("1")}); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	row, ok, err := b.Get(ctx, "t", "a")
+	if err != nil || !ok || string(row.Value) != "1" {
+		t.Fatalf("Expected (1, true), got (%q, %v), err=%v", row.Value, ok, err)
+	}
+
+	existed, err := b.Delete(ctx, "t", "a")
+	if err != nil || !existed {
+		t.Fatalf("Expected Delete to report existed=true, got %v, err=%v", existed, err)
+	}
+	if _, ok, err := b.Get(ctx, "t", "a"); err != nil || ok {
+		t.Fatalf("Expected Get to report ok=false after delete, got %v, err=%v", ok, err)
+	}
+}
+
+func TestBackendExpiry(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_bbolt_backend_expiry.db")
+
+	db, err := bolt.Open(dbPath, 0600, nil)
+	if err != nil {
+		t.Fatalf("Failed to open db: %v", err)
+	}
+	defer db.Close()
+
+	b := bboltbackend.New(db)
+	ctx := context.Background()
+	if err := b.EnsureTable(ctx, "t"); err != nil {
+		t.Fatalf("EnsureTable failed: %v", err)
+	}
+
+	expiry := time.Now().Add(-time.Second).UnixNano()
+	if err := b.Put(ctx, "t", "a", backend.Row{Value: []byte("1"), Expiry: &expiry}); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if _, ok, err := b.Get(ctx, "t", "a"); err != nil || ok {
+		t.Fatalf("Expected Get to treat an expired row as absent, got ok=%v, err=%v", ok, err)
+	}
+}
@@ -0,0 +1,79 @@
+// Package sqlite is kvstore's default Backend: it adapts an already-open
+// *sql.DB to backend.Backend using the same key/value/expiry schema the
+// core package has always used.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/xtdlib/kvstore/backend"
+)
+
+// Backend adapts db to backend.Backend. kvstore.New/NewAt construct one of
+// these automatically unless a WithBackend option overrides it.
+type Backend struct {
+	DB *sql.DB
+}
+
+// New wraps an already-open *sql.DB.
+func New(db *sql.DB) *Backend {
+	return &Backend{DB: db}
+}
+
+func (b *Backend) EnsureTable(ctx context.Context, table string) error {
+	createSQL := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (key PRIMARY KEY, value, expiry INTEGER)", table)
+	_, err := b.DB.ExecContext(ctx, createSQL)
+	return err
+}
+
+func (b *Backend) Get(ctx context.Context, table, key string) (backend.Row, bool, error) {
+	query := fmt.Sprintf("SELECT value, expiry FROM %s WHERE key = ? AND (expiry IS NULL OR expiry > ?)", table)
+	var row backend.Row
+	var expiry sql.NullInt64
+	err := b.DB.QueryRowContext(ctx, query, key, time.Now().UnixNano()).Scan(&row.Value, &expiry)
+	if err == sql.ErrNoRows {
+		return backend.Row{}, false, nil
+	}
+	if err != nil {
+		return backend.Row{}, false, err
+	}
+	if expiry.Valid {
+		row.Expiry = &expiry.Int64
+	}
+	return row, true, nil
+}
+
+func (b *Backend) Put(ctx context.Context, table, key string, row backend.Row) error {
+	var expiry any
+	if row.Expiry != nil {
+		expiry = *row.Expiry
+	}
+	// INSERT OR REPLACE discards the whole previous row, so a bare
+	// Put(..., Row{Value: v}) clears any expiry the key had before,
+	// matching TrySet's existing "plain Set cancels TTL" behavior.
+	query := fmt.Sprintf("INSERT OR REPLACE INTO %s (key, value, expiry) VALUES (?, ?, ?)", table)
+	_, err := b.DB.ExecContext(ctx, query, key, row.Value, expiry)
+	return err
+}
+
+func (b *Backend) Delete(ctx context.Context, table, key string) (bool, error) {
+	query := fmt.Sprintf("DELETE FROM %s WHERE key = ?", table)
+	result, err := b.DB.ExecContext(ctx, query, key)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (b *Backend) Clear(ctx context.Context, table string) error {
+	_, err := b.DB.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s", table))
+	return err
+}
+
+func (b *Backend) Close() error {
+	return b.DB.Close()
+}
@@ -0,0 +1,73 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/xtdlib/kvstore/backend"
+	"github.com/xtdlib/kvstore/backend/sqlite"
+)
+
+func TestBackendSatisfiesBackend(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_sqlite_backend.db")
+
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open db: %v", err)
+	}
+	defer db.Close()
+
+	var b backend.Backend = sqlite.New(db)
+	ctx := context.Background()
+
+	if err := b.EnsureTable(ctx, "t"); err != nil {
+		t.Fatalf("EnsureTable failed: %v", err)
+	}
+
+	if err := b.Put(ctx, "t", "a", backend.Row{Value: []byte("1")}); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	row, ok, err := b.Get(ctx, "t", "a")
+	if err != nil || !ok || string(row.Value) != "1" {
+		t.Fatalf("Expected (1, true), got (%q, %v), err=%v", row.Value, ok, err)
+	}
+
+	existed, err := b.Delete(ctx, "t", "a")
+	if err != nil || !existed {
+		t.Fatalf("Expected Delete to report existed=true, got %v, err=%v", existed, err)
+	}
+	if _, ok, err := b.Get(ctx, "t", "a"); err != nil || ok {
+		t.Fatalf("Expected Get to report ok=false after delete, got %v, err=%v", ok, err)
+	}
+}
+
+func TestBackendClear(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_sqlite_backend_clear.db")
+
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open db: %v", err)
+	}
+	defer db.Close()
+
+	b := sqlite.New(db)
+	ctx := context.Background()
+	if err := b.EnsureTable(ctx, "t"); err != nil {
+		t.Fatalf("EnsureTable failed: %v", err)
+	}
+	b.Put(ctx, "t", "a", backend.Row{Value: []byte("1")})
+	b.Put(ctx, "t", "b", backend.Row{Value: []byte("2")})
+
+	if err := b.Clear(ctx, "t"); err != nil {
+		t.Fatalf("Clear failed: %v", err)
+	}
+	if _, ok, _ := b.Get(ctx, "t", "a"); ok {
+		t.Fatal("Expected Clear to remove every row")
+	}
+}
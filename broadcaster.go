@@ -0,0 +1,274 @@
+package kvstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// ErrSlowConsumer is the error a CloseWithError subscriber's channel is
+// associated with when the broadcaster gives up on it. WatchEvent itself
+// carries no error field, so the channel is closed after one best-effort
+// WatchEventLagged sentinel rather than threading this error through it;
+// it exists so callers have a named reason to log or compare against.
+var ErrSlowConsumer = errors.New("kvstore: subscriber too slow, channel closed")
+
+// OverflowPolicy controls what happens to a subscriber whose buffered
+// channel is still full when a new event needs to be delivered to it.
+type OverflowPolicy int
+
+const (
+	// DropNewest silently skips this event for the lagging subscriber but
+	// leaves it subscribed; it may still receive future events.
+	DropNewest OverflowPolicy = iota
+	// DropOldest evicts the subscriber's oldest buffered, not-yet-delivered
+	// event to make room for this one, so a subscriber only ever lags on
+	// staleness, never on missing the most recent state.
+	DropOldest
+	// CloseWithError evicts the subscriber's oldest buffered event, sends
+	// one WatchEventLagged sentinel in its place, and then closes the
+	// subscriber's channel, so a stuck consumer can never
+	// block writers indefinitely. See ErrSlowConsumer.
+	CloseWithError
+)
+
+const defaultWatchBufSize = 10
+
+// WatchOption configures a Watch/WatchPrefix/WatchRange subscription.
+type WatchOption func(*watchConfig)
+
+type watchConfig struct {
+	bufSize int
+	policy  OverflowPolicy
+}
+
+func defaultWatchConfig() watchConfig {
+	return watchConfig{bufSize: defaultWatchBufSize, policy: DropNewest}
+}
+
+// WithBufferSize overrides the default buffered channel size for a watch.
+func WithBufferSize(n int) WatchOption {
+	return func(c *watchConfig) { c.bufSize = n }
+}
+
+// WithOverflowPolicy overrides the default behavior for a slow subscriber.
+func WithOverflowPolicy(p OverflowPolicy) WatchOption {
+	return func(c *watchConfig) { c.policy = p }
+}
+
+// matcher reports whether a key's string form (its JSON-encoded bytes, via
+// keyString below) should be delivered to a subscriber.
+type matcher func(keyStr string) bool
+
+type subscription[T1 comparable, T2 comparable] struct {
+	id     uint64
+	ch     chan WatchEvent[T1, T2]
+	match  matcher
+	policy OverflowPolicy
+}
+
+type change[T1 comparable, T2 comparable] struct {
+	keyStr string
+	event  WatchEvent[T1, T2]
+	// bulk marks a change that bypasses every subscriber's matcher, for
+	// WatchEventBulk events that aren't about any single key.
+	bulk bool
+}
+
+// broadcaster is the single fan-out point for one table's watch events.
+// A background goroutine reads change notifications off a channel and
+// delivers them to every matching subscriber, so a slow or stuck consumer
+// can never block the writer that produced the change. stopAll shuts that
+// goroutine down and, via wg, waits for it to actually exit before
+// returning, so callers never race a publish against a closed ingest
+// channel or observe StopAllWatchers return before dispatch has drained.
+type broadcaster[T1 comparable, T2 comparable] struct {
+	mu     sync.Mutex
+	nextID uint64
+	subs   map[uint64]*subscription[T1, T2]
+
+	ingest    chan change[T1, T2]
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+func newBroadcaster[T1 comparable, T2 comparable]() *broadcaster[T1, T2] {
+	b := &broadcaster[T1, T2]{
+		subs:   make(map[uint64]*subscription[T1, T2]),
+		ingest: make(chan change[T1, T2], 256),
+	}
+	b.wg.Add(1)
+	go b.run()
+	return b
+}
+
+func (b *broadcaster[T1, T2]) run() {
+	defer b.wg.Done()
+	for c := range b.ingest {
+		b.dispatch(c)
+	}
+}
+
+// publish enqueues a change for fan-out. It never blocks on a subscriber.
+// It is a no-op after stopAll has closed the ingest channel.
+func (b *broadcaster[T1, T2]) publish(keyStr string, event WatchEvent[T1, T2]) {
+	defer func() { recover() }() // ingest closed by a concurrent stopAll
+	b.ingest <- change[T1, T2]{keyStr: keyStr, event: event}
+}
+
+// publishAll enqueues a single event to be delivered to every subscriber
+// regardless of its matcher, for bulk events (see WatchEventBulk) that
+// aren't about any single key. It never blocks on a subscriber, matching
+// publish's semantics.
+func (b *broadcaster[T1, T2]) publishAll(event WatchEvent[T1, T2]) {
+	defer func() { recover() }() // ingest closed by a concurrent stopAll
+	b.ingest <- change[T1, T2]{event: event, bulk: true}
+}
+
+// dispatch holds b.mu for its entire matched-and-deliver pass, rather than
+// snapshotting matched subscribers and releasing the lock before sending to
+// them. Every send below is non-blocking (select/default), so this never
+// stalls on a subscriber; what it buys is that stopAll (which also takes
+// b.mu to remove and close subscriptions) can never interleave between
+// "this subscription is still registered" and "send on its channel" and
+// close a channel out from under a send in progress here.
+func (b *broadcaster[T1, T2]) dispatch(c change[T1, T2]) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, sub := range b.subs {
+		if c.bulk || sub.match(c.keyStr) {
+			b.deliverLocked(sub, c.event)
+		}
+	}
+}
+
+// deliverLocked requires b.mu to already be held; see dispatch.
+func (b *broadcaster[T1, T2]) deliverLocked(sub *subscription[T1, T2], event WatchEvent[T1, T2]) {
+	select {
+	case sub.ch <- event:
+		return
+	default:
+	}
+
+	switch sub.policy {
+	case DropOldest:
+		select {
+		case <-sub.ch: // evict the oldest buffered event
+		default:
+		}
+		select {
+		case sub.ch <- event:
+		default: // lost the race to another delivery; drop this one
+		}
+
+	case CloseWithError:
+		var lagged WatchEvent[T1, T2]
+		lagged.Type = WatchEventLagged
+		select {
+		case <-sub.ch: // evict the oldest buffered event to make room
+		default:
+		}
+		select {
+		case sub.ch <- lagged:
+		default:
+		}
+		b.closeAndRemoveLocked(sub.id)
+
+	default: // DropNewest
+	}
+}
+
+// subscribe registers match as a filter over a new buffered channel, and
+// arranges for it to be unregistered automatically when ctx is canceled
+// (via context.AfterFunc). ctx may be nil, in which case the returned
+// CancelFunc is the only way to unsubscribe.
+func (b *broadcaster[T1, T2]) subscribe(ctx context.Context, cfg watchConfig, match matcher) (<-chan WatchEvent[T1, T2], CancelFunc) {
+	b.mu.Lock()
+	id := b.nextID
+	b.nextID++
+	sub := &subscription[T1, T2]{
+		id:     id,
+		ch:     make(chan WatchEvent[T1, T2], cfg.bufSize),
+		match:  match,
+		policy: cfg.policy,
+	}
+	b.subs[id] = sub
+	b.mu.Unlock()
+
+	remove := func() { b.closeAndRemove(id) }
+
+	if ctx != nil {
+		stop := context.AfterFunc(ctx, remove)
+		return sub.ch, func() { stop(); remove() }
+	}
+	return sub.ch, remove
+}
+
+func (b *broadcaster[T1, T2]) closeAndRemove(id uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closeAndRemoveLocked(id)
+}
+
+// closeAndRemoveLocked requires b.mu to already be held; see dispatch.
+func (b *broadcaster[T1, T2]) closeAndRemoveLocked(id uint64) {
+	if sub, ok := b.subs[id]; ok {
+		delete(b.subs, id)
+		close(sub.ch)
+	}
+}
+
+// stopAll unregisters and closes every subscriber, then shuts down the
+// dispatch goroutine and blocks until it has actually exited, so that once
+// stopAll returns no more events can possibly be delivered and it is safe
+// to e.g. assert on channels remaining empty without a sleep.
+func (b *broadcaster[T1, T2]) stopAll() {
+	b.mu.Lock()
+	for id, sub := range b.subs {
+		delete(b.subs, id)
+		close(sub.ch)
+	}
+	b.mu.Unlock()
+
+	b.closeOnce.Do(func() { close(b.ingest) })
+	b.wg.Wait()
+}
+
+func exactMatcher(key string) matcher {
+	return func(keyStr string) bool { return keyStr == key }
+}
+
+// prefixMatcher takes a keyString-encoded prefix. A string prefix's JSON
+// form ends in a closing quote that the longer keys it prefixes do not have
+// at that position, so that quote is dropped first, as likePattern does.
+func prefixMatcher(prefix string) matcher {
+	prefix = strings.TrimSuffix(prefix, "\"")
+	return func(keyStr string) bool { return strings.HasPrefix(keyStr, prefix) }
+}
+
+func rangeMatcher(lo, hi string) matcher {
+	return func(keyStr string) bool { return keyStr >= lo && keyStr < hi }
+}
+
+func allMatcher() matcher {
+	return func(keyStr string) bool { return true }
+}
+
+// keyString renders key the same way it is stored in the key column: its
+// JSON-encoded bytes. Using the stored encoding, rather than fmt.Sprintf's
+// "%v", is what makes prefixMatcher/rangeMatcher's lexical comparisons
+// agree with the "ORDER BY key" iteration Iter/Range/IterReverse already
+// use, and what makes them meaningful at all for int/struct keys (whose
+// "%v" form has no relationship to their on-disk byte order).
+func keyString[T1 comparable](key T1) string {
+	b, err := json.Marshal(key)
+	if err != nil {
+		// Keys are required to be JSON-marshalable everywhere else in this
+		// package; this only protects against a key type escaping that
+		// contract in some exotic way.
+		return ""
+	}
+	return string(b)
+}
@@ -0,0 +1,144 @@
+package kvstore_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xtdlib/kvstore"
+)
+
+func TestWatchOverflowDropOldestKeepsLatest(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_overflow_drop_oldest.db")
+
+	store, err := kvstore.NewAt[string, int](dbPath, "test_overflow_drop_oldest")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	eventCh, cancel := store.WatchPrefix(context.Background(), "k",
+		kvstore.WithBufferSize(1), kvstore.WithOverflowPolicy(kvstore.DropOldest))
+	defer cancel()
+
+	store.Set("k1", 1)
+	store.Set("k2", 2)
+	store.Set("k3", 3)
+
+	select {
+	case event := <-eventCh:
+		if event.Value != 3 {
+			t.Fatalf("Expected DropOldest to keep the latest event (3), got %d", event.Value)
+		}
+	case <-time.After(1 * time.Second):
+		t.Fatal("Timeout waiting for event")
+	}
+}
+
+func TestWatchOverflowCloseWithErrorClosesChannel(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_overflow_close.db")
+
+	store, err := kvstore.NewAt[string, int](dbPath, "test_overflow_close")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	eventCh, cancel := store.WatchPrefix(context.Background(), "k",
+		kvstore.WithBufferSize(1), kvstore.WithOverflowPolicy(kvstore.CloseWithError))
+	defer cancel()
+
+	store.Set("k1", 1)
+	store.Set("k2", 2)
+	store.Set("k3", 3)
+
+	// Drain whatever made it into the buffer; the channel must eventually
+	// deliver a WatchEventLagged sentinel and then close.
+	sawLagged := false
+	for i := 0; i < 10; i++ {
+		select {
+		case event, ok := <-eventCh:
+			if !ok {
+				if !sawLagged {
+					t.Fatal("Channel closed without a WatchEventLagged sentinel")
+				}
+				return
+			}
+			if event.Type == kvstore.WatchEventLagged {
+				sawLagged = true
+			}
+		case <-time.After(1 * time.Second):
+			t.Fatal("Timeout waiting for channel to close")
+		}
+	}
+	t.Fatal("Expected channel to close within a few events")
+}
+
+func TestStopAllWatchersSynchronouslyDrains(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_stop_all_sync.db")
+
+	store, err := kvstore.NewAt[string, string](dbPath, "test_stop_all_sync")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	eventCh, cancel := store.Watch("key1")
+	defer cancel()
+
+	// StopAllWatchers must block until the dispatch goroutine has fully
+	// drained, so the subsequent assertion needs no time.Sleep.
+	store.StopAllWatchers()
+
+	if _, ok := <-eventCh; ok {
+		t.Fatal("Expected watch channel to already be closed after StopAllWatchers returns")
+	}
+}
+
+// TestStopAllWatchersDoesNotRaceConcurrentSets hammers Set from many
+// goroutines while repeatedly subscribing and calling StopAllWatchers
+// concurrently from others. A dispatch goroutine that sends on a
+// subscriber's channel after StopAllWatchers has already closed it panics
+// with "send on closed channel"; this only reliably reproduces under -race
+// but is cheap enough to always run.
+func TestStopAllWatchersDoesNotRaceConcurrentSets(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_stop_all_race.db")
+
+	store, err := kvstore.NewAt[string, string](dbPath, "test_stop_all_race")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				store.Set(fmt.Sprintf("key%d", worker), fmt.Sprintf("v%d", j))
+			}
+		}(i)
+	}
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				eventCh, cancel := store.Watch("key0")
+				go func() {
+					for range eventCh {
+					}
+				}()
+				store.StopAllWatchers()
+				cancel()
+			}
+		}()
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,263 @@
+package kvstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"time"
+)
+
+const defaultBulkBatchSize = 1000
+
+// OnConflictPolicy selects how BulkImport/SetMany handle a key that
+// already has a value in the store.
+type OnConflictPolicy int
+
+const (
+	// Replace overwrites the existing value, the same semantics as
+	// TrySet/INSERT OR REPLACE. This is the default.
+	Replace OnConflictPolicy = iota
+	// Ignore leaves the existing value untouched and counts the row as
+	// skipped.
+	Ignore
+	// Fail aborts the rest of the import, rolling back its current batch,
+	// the first time an imported key already exists.
+	Fail
+)
+
+// BulkOpts configures SetMany/BulkImport.
+type BulkOpts struct {
+	// OnConflict selects what happens when an imported key already has a
+	// value. Defaults to Replace.
+	OnConflict OnConflictPolicy
+	// BatchSize is how many rows are committed per transaction. <= 0
+	// means the default of 1000.
+	BatchSize int
+	// DisableWatchers skips publishing the coalesced WatchEventBulk event
+	// for this import, for cold loads where nobody is listening yet.
+	DisableWatchers bool
+	// PreDeleteAll wraps the import in a DELETE FROM <table> first, for
+	// idempotent re-imports of a full dataset.
+	PreDeleteAll bool
+}
+
+// ImportStats reports the outcome of a BulkImport/SetMany call.
+type ImportStats struct {
+	Inserted int
+	Updated  int
+	Skipped  int
+	Duration time.Duration
+}
+
+// SetMany writes every entry in a single BulkImport call (default
+// BulkOpts) rather than one TrySet per entry, so importing a large map
+// doesn't take a separate SQLite write lock per row.
+func (s *KV[T1, T2]) SetMany(entries map[T1]T2) error {
+	src := func(yield func(T1, T2) bool) {
+		for k, v := range entries {
+			if !yield(k, v) {
+				return
+			}
+		}
+	}
+	_, err := s.BulkImport(src, BulkOpts{})
+	return err
+}
+
+// BulkImport writes every pair src yields using a single transaction per
+// opts.BatchSize rows (default 1000) and a prepared insert statement,
+// rather than TrySet's one-statement-per-call, its-own-context approach.
+// Each row still keeps every registered index (see AddIndex) synchronized
+// in the same batch transaction, and still appends a history entry,
+// exactly as TrySet does. Unless opts.DisableWatchers is set, it publishes
+// exactly one coalesced WatchEventBulk event after the last batch
+// commits, instead of one event per row.
+func (s *KV[T1, T2]) BulkImport(src iter.Seq2[T1, T2], opts BulkOpts) (ImportStats, error) {
+	start := time.Now()
+	var stats ImportStats
+
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBulkBatchSize
+	}
+
+	ctx := context.Background()
+
+	if opts.PreDeleteAll {
+		if _, err := s.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s", s.table)); err != nil {
+			return stats, err
+		}
+	}
+
+	b := &bulkBatch[T1, T2]{s: s, ctx: ctx, indexes: s.indexList()}
+	if err := b.begin(); err != nil {
+		return stats, err
+	}
+
+	n := 0
+	for key, value := range src {
+		existed, err := b.put(key, value, opts.OnConflict)
+		if err != nil {
+			b.rollback()
+			return stats, err
+		}
+
+		switch {
+		case !existed:
+			stats.Inserted++
+		case opts.OnConflict == Ignore:
+			stats.Skipped++
+		default:
+			stats.Updated++
+		}
+
+		n++
+		if n >= batchSize {
+			if err := b.commit(); err != nil {
+				return stats, err
+			}
+			if err := b.begin(); err != nil {
+				return stats, err
+			}
+			n = 0
+		}
+	}
+
+	if err := b.commit(); err != nil {
+		return stats, err
+	}
+
+	stats.Duration = time.Since(start)
+
+	if !opts.DisableWatchers && s.broadcaster != nil {
+		s.broadcaster.publishAll(WatchEvent[T1, T2]{Type: WatchEventBulk, Stats: stats})
+	}
+
+	return stats, nil
+}
+
+// bulkBatch holds the transaction and prepared statements for one commit
+// window of a BulkImport call, plus the snapshot of registered indexes
+// (see AddIndex) taken once at the start of the call.
+type bulkBatch[T1 comparable, T2 comparable] struct {
+	s       *KV[T1, T2]
+	ctx     context.Context
+	indexes []*indexInfo[T1, T2]
+	tx      *sql.Tx
+	ins     *sql.Stmt
+	exst    *sql.Stmt
+}
+
+func (b *bulkBatch[T1, T2]) begin() error {
+	tx, err := beginImmediate(b.ctx, b.s.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	ins, err := tx.Prepare(fmt.Sprintf("INSERT OR REPLACE INTO %s (key, value) VALUES (?, ?)", b.s.table))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	// No expiry filter here, unlike getOldValue's existing-value lookup
+	// for TrySet (which goes through the Backend's expiry-aware Get): an
+	// expired-but-unreaped row counts as existing for BulkImport's
+	// existed/Inserted vs Updated and for its OnConflict policy.
+	exst, err := tx.Prepare(fmt.Sprintf("SELECT value FROM %s WHERE key = ?", b.s.table))
+	if err != nil {
+		ins.Close()
+		tx.Rollback()
+		return err
+	}
+
+	b.tx, b.ins, b.exst = tx, ins, exst
+	return nil
+}
+
+func (b *bulkBatch[T1, T2]) commit() error {
+	b.ins.Close()
+	b.exst.Close()
+	err := b.tx.Commit()
+	b.tx, b.ins, b.exst = nil, nil, nil
+	return err
+}
+
+func (b *bulkBatch[T1, T2]) rollback() {
+	if b.tx == nil {
+		return
+	}
+	b.ins.Close()
+	b.exst.Close()
+	b.tx.Rollback()
+	b.tx, b.ins, b.exst = nil, nil, nil
+}
+
+// put writes one row according to policy, keeps every registered index in
+// sync and appends a history entry in the same batch transaction, and
+// reports whether the key already had a value.
+func (b *bulkBatch[T1, T2]) put(key T1, value T2, policy OnConflictPolicy) (existed bool, err error) {
+	keyBytes, err := b.s.kc().Marshal(key)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal key: %w", err)
+	}
+	valueBytes, err := b.s.vc().Marshal(value)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal value: %w", err)
+	}
+
+	var oldValueBytes []byte
+	err = b.exst.QueryRow(string(keyBytes)).Scan(&oldValueBytes)
+	if err != nil && err != sql.ErrNoRows {
+		return false, err
+	}
+	existed = err == nil
+
+	if existed && policy == Fail {
+		return true, fmt.Errorf("kvstore: key already exists during bulk import with OnConflict=Fail")
+	}
+	if existed && policy == Ignore {
+		return true, nil
+	}
+
+	var oldValue T2
+	action := Create
+	if existed {
+		if err := b.s.vc().Unmarshal(oldValueBytes, &oldValue); err != nil {
+			return existed, fmt.Errorf("failed to unmarshal value: %w", err)
+		}
+		action = Update
+	}
+
+	if _, err := b.ins.Exec(string(keyBytes), valueBytes); err != nil {
+		return existed, err
+	}
+
+	for _, idx := range b.indexes {
+		if _, err := b.tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE primary_key = ?", idx.table), keyBytes); err != nil {
+			return existed, err
+		}
+		insertIdxSQL := fmt.Sprintf("INSERT INTO %s (indexed_key, primary_key) VALUES (?, ?)", idx.table)
+		if _, err := b.tx.Exec(insertIdxSQL, idx.extract(key, value), keyBytes); err != nil {
+			return existed, err
+		}
+	}
+
+	// Written on b.tx rather than via appendHistory, whose separate
+	// connection would wait on this batch's own write lock.
+	prevJSON, err := json.Marshal(oldValue)
+	if err != nil {
+		return existed, fmt.Errorf("failed to marshal prev value: %w", err)
+	}
+	newJSON, err := json.Marshal(value)
+	if err != nil {
+		return existed, fmt.Errorf("failed to marshal new value: %w", err)
+	}
+	histSQL := fmt.Sprintf("INSERT INTO %s (key, prev_value, new_value, action, ts) VALUES (?, ?, ?, ?, ?)", b.s.historyTable())
+	if _, err := b.tx.Exec(histSQL, string(keyBytes), string(prevJSON), string(newJSON), int(action), time.Now().UnixNano()); err != nil {
+		return existed, err
+	}
+
+	return existed, nil
+}
@@ -0,0 +1,203 @@
+package kvstore_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/xtdlib/kvstore"
+)
+
+func TestSetMany(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_set_many.db")
+
+	store, err := kvstore.NewAt[string, int](dbPath, "test_set_many")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	if err := store.SetMany(map[string]int{"a": 1, "b": 2, "c": 3}); err != nil {
+		t.Fatalf("SetMany failed: %v", err)
+	}
+
+	if store.Get("a") != 1 || store.Get("b") != 2 || store.Get("c") != 3 {
+		t.Fatalf("SetMany did not write the expected values")
+	}
+}
+
+func TestBulkImportStatsAndConflictPolicies(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_bulk_import.db")
+
+	store, err := kvstore.NewAt[string, int](dbPath, "test_bulk_import")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	store.Set("existing", 1)
+
+	src := func(yield func(string, int) bool) {
+		pairs := []struct {
+			k string
+			v int
+		}{{"existing", 99}, {"new", 2}}
+		for _, p := range pairs {
+			if !yield(p.k, p.v) {
+				return
+			}
+		}
+	}
+
+	stats, err := store.BulkImport(src, kvstore.BulkOpts{})
+	if err != nil {
+		t.Fatalf("BulkImport failed: %v", err)
+	}
+	if stats.Inserted != 1 || stats.Updated != 1 || stats.Skipped != 0 {
+		t.Fatalf("Unexpected stats: %+v", stats)
+	}
+	if store.Get("existing") != 99 {
+		t.Fatalf("Expected Replace to overwrite existing, got %d", store.Get("existing"))
+	}
+
+	stats, err = store.BulkImport(src, kvstore.BulkOpts{OnConflict: kvstore.Ignore})
+	if err != nil {
+		t.Fatalf("BulkImport with Ignore failed: %v", err)
+	}
+	if stats.Skipped != 2 || stats.Inserted != 0 {
+		t.Fatalf("Unexpected stats with Ignore: %+v", stats)
+	}
+	if store.Get("existing") != 99 {
+		t.Fatalf("Expected Ignore to leave existing value untouched, got %d", store.Get("existing"))
+	}
+
+	if _, err := store.BulkImport(src, kvstore.BulkOpts{OnConflict: kvstore.Fail}); err == nil {
+		t.Fatal("Expected BulkImport with Fail to error on an existing key")
+	}
+}
+
+func TestBulkImportPreDeleteAll(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_bulk_predelete.db")
+
+	store, err := kvstore.NewAt[string, int](dbPath, "test_bulk_predelete")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	store.Set("stale", 1)
+
+	src := func(yield func(string, int) bool) { yield("fresh", 2) }
+	if _, err := store.BulkImport(src, kvstore.BulkOpts{PreDeleteAll: true}); err != nil {
+		t.Fatalf("BulkImport failed: %v", err)
+	}
+
+	if store.Has("stale") {
+		t.Fatal("Expected PreDeleteAll to remove stale rows")
+	}
+	if !store.Has("fresh") {
+		t.Fatal("Expected fresh row to be imported")
+	}
+}
+
+func TestBulkImportKeepsIndexesAndHistoryInSync(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_bulk_index_history.db")
+
+	store, err := kvstore.NewAt[string, int](dbPath, "test_bulk_index_history")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	store.Set("existing", 1)
+
+	entries, _ := store.History("existing", 0, 0)
+	rev1 := entries[0].Rev
+
+	err = store.AddIndex("parity", func(_ string, v int) []byte {
+		return []byte(fmt.Sprintf("%d", v%2))
+	})
+	if err != nil {
+		t.Fatalf("AddIndex failed: %v", err)
+	}
+
+	src := func(yield func(string, int) bool) {
+		pairs := []struct {
+			k string
+			v int
+		}{{"existing", 2}, {"new", 3}}
+		for _, p := range pairs {
+			if !yield(p.k, p.v) {
+				return
+			}
+		}
+	}
+	if _, err := store.BulkImport(src, kvstore.BulkOpts{}); err != nil {
+		t.Fatalf("BulkImport failed: %v", err)
+	}
+
+	got, err := store.Lookup("parity", []byte("0"))
+	if err != nil {
+		t.Fatalf("Lookup failed: %v", err)
+	}
+	if len(got) != 1 || got[0] != 2 {
+		t.Fatalf("Expected index to reflect bulk-imported value, got %+v", got)
+	}
+
+	got, err = store.Lookup("parity", []byte("1"))
+	if err != nil {
+		t.Fatalf("Lookup failed: %v", err)
+	}
+	if len(got) != 1 || got[0] != 3 {
+		t.Fatalf("Expected index to reflect bulk-inserted value, got %+v", got)
+	}
+
+	val, modRev, err := store.GetRev("existing", rev1)
+	if err != nil {
+		t.Fatalf("GetRev failed: %v", err)
+	}
+	if val != 1 || modRev != rev1 {
+		t.Fatalf("Expected BulkImport to preserve pre-import history, got %d at rev %d", val, modRev)
+	}
+}
+
+func TestBulkImportPublishesSingleCoalescedEvent(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_bulk_watch.db")
+
+	store, err := kvstore.NewAt[string, int](dbPath, "test_bulk_watch")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	eventCh, cancel := store.WatchAll(context.Background())
+	defer cancel()
+
+	src := func(yield func(string, int) bool) {
+		yield("a", 1)
+		yield("b", 2)
+	}
+	if _, err := store.BulkImport(src, kvstore.BulkOpts{}); err != nil {
+		t.Fatalf("BulkImport failed: %v", err)
+	}
+
+	select {
+	case event := <-eventCh:
+		if event.Type != kvstore.WatchEventBulk {
+			t.Fatalf("Expected WatchEventBulk, got %+v", event)
+		}
+		if event.Stats.Inserted != 2 {
+			t.Fatalf("Expected Stats.Inserted=2, got %+v", event.Stats)
+		}
+	case <-time.After(1 * time.Second):
+		t.Fatal("Timeout waiting for bulk event")
+	}
+
+	select {
+	case event := <-eventCh:
+		t.Fatalf("Expected exactly one coalesced event, got an extra %+v", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
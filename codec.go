@@ -0,0 +1,82 @@
+package kvstore
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+)
+
+// Codec marshals and unmarshals values for storage. Name identifies the
+// codec in a store's "<table>_meta" table so an existing DB opened with a
+// different codec fails loudly instead of silently corrupting values.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+	Name() string
+}
+
+// KeyCodec is a Codec used for keys. Its Marshal must be canonical: equal
+// keys always produce byte-equal output, since the marshaled bytes are the
+// table's PRIMARY KEY. JSONCodec and GobCodec are canonical for the
+// comparable key types this package supports (no maps, no NaN floats).
+type KeyCodec = Codec
+
+// JSONCodec is the codec kvstore has always used: encoding/json for both
+// keys and values. It is the default for New/NewAt.
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (JSONCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+func (JSONCodec) Name() string                       { return "json" }
+
+// GobCodec encodes with encoding/gob, useful for Go struct values that
+// don't round-trip cleanly through JSON (e.g. fields that must stay
+// unexported-but-registered, or types with custom GobEncode/GobDecode).
+type GobCodec struct{}
+
+func (GobCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GobCodec) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+func (GobCodec) Name() string { return "gob" }
+
+// RawBytesCodec is a fast path for []byte and string values: it stores the
+// bytes as-is instead of paying JSON's base64/quoting overhead. Marshal
+// returns an error if v is not a []byte or string, and Unmarshal returns an
+// error if v is not a *[]byte or *string.
+type RawBytesCodec struct{}
+
+func (RawBytesCodec) Marshal(v any) ([]byte, error) {
+	switch val := v.(type) {
+	case []byte:
+		return val, nil
+	case string:
+		return []byte(val), nil
+	default:
+		return nil, fmt.Errorf("kvstore: RawBytesCodec only supports []byte and string, got %T", v)
+	}
+}
+
+func (RawBytesCodec) Unmarshal(data []byte, v any) error {
+	switch ptr := v.(type) {
+	case *[]byte:
+		*ptr = append([]byte(nil), data...)
+		return nil
+	case *string:
+		*ptr = string(data)
+		return nil
+	default:
+		return fmt.Errorf("kvstore: RawBytesCodec only supports *[]byte and *string, got %T", v)
+	}
+}
+
+func (RawBytesCodec) Name() string { return "rawbytes" }
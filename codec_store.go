@@ -0,0 +1,100 @@
+package kvstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+func (s *KV[T1, T2]) metaTable() string {
+	return s.table + "_meta"
+}
+
+// ensureCodecMeta records keyCodec/valueCodec's names in a companion
+// "<table>_meta" table the first time the store is opened, and fails
+// loudly if a later open names different codecs than what's on disk —
+// opening a GobCodec-written table with JSONCodec would otherwise silently
+// return garbage instead of an error.
+func (s *KV[T1, T2]) ensureCodecMeta(ctx context.Context) error {
+	createSQL := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		key_codec TEXT,
+		value_codec TEXT
+	)`, s.metaTable())
+	if _, err := s.db.ExecContext(ctx, createSQL); err != nil {
+		return err
+	}
+
+	var existingKeyCodec, existingValueCodec string
+	query := fmt.Sprintf("SELECT key_codec, value_codec FROM %s LIMIT 1", s.metaTable())
+	err := s.db.QueryRowContext(ctx, query).Scan(&existingKeyCodec, &existingValueCodec)
+	if err == sql.ErrNoRows {
+		insertSQL := fmt.Sprintf("INSERT INTO %s (key_codec, value_codec) VALUES (?, ?)", s.metaTable())
+		_, err := s.db.ExecContext(ctx, insertSQL, s.kc().Name(), s.vc().Name())
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if existingKeyCodec != s.kc().Name() || existingValueCodec != s.vc().Name() {
+		return fmt.Errorf("kvstore: table %s was created with codecs (key=%s, value=%s), got (key=%s, value=%s)",
+			s.table, existingKeyCodec, existingValueCodec, s.kc().Name(), s.vc().Name())
+	}
+	return nil
+}
+
+// NewAtWithCodec is NewAt with explicit key/value codecs instead of the
+// default JSONCodec. See Codec and KeyCodec for the built-ins (JSONCodec,
+// GobCodec, RawBytesCodec) and their tradeoffs.
+//
+// keyCodec must be JSONCodec: Range/RangePrefix/DeleteRange/DeletePrefix/
+// CountPrefix and the WatchPrefix/WatchRange matchers all build their SQL
+// bounds (and, for watch matching, compare keys) via raw encoding/json
+// rather than through a configured KeyCodec, so a non-JSON KeyCodec would
+// silently corrupt every one of those. valueCodec encodes and decodes
+// values for TrySet/TryGet/TryDelete, Iter/All/ForEach/Backward,
+// BulkImport/SetMany, AddIndex/Lookup/RangeIndex, and CompareAndSwap/
+// CreateOnly on a store without secondary indexes. Range/RangePrefix,
+// DeleteRange/DeletePrefix, SetTTL/SetWithExpiry, the TTL reaper, and
+// Transaction/TxnOps (Tx.Set/Tx.Get/Tx.ForEach and OpRange) encode and
+// decode values with encoding/json regardless of valueCodec, so with a
+// non-JSON valueCodec they do not read or write values in that codec.
+func NewAtWithCodec[T1 comparable, T2 comparable](dbPath string, name string, keyCodec KeyCodec, valueCodec Codec, opts ...Option) (*KV[T1, T2], error) {
+	if keyCodec.Name() != (JSONCodec{}).Name() {
+		return nil, fmt.Errorf("kvstore: NewAtWithCodec requires a JSON KeyCodec on table %s; got %q, and range/prefix queries and watch matching still build SQL bounds assuming JSON-encoded keys", name, keyCodec.Name())
+	}
+
+	store, err := NewAt[T1, T2](dbPath, name, opts...)
+	if err != nil {
+		return nil, err
+	}
+	store.keyCodec = keyCodec
+	store.valueCodec = valueCodec
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := store.ensureCodecMeta(ctx); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// NewWithCodec is New with explicit key/value codecs instead of the
+// default JSONCodec. See NewAtWithCodec.
+func NewWithCodec[T1 comparable, T2 comparable](name string, keyCodec KeyCodec, valueCodec Codec, opts ...Option) (*KV[T1, T2], error) {
+	if keyCodec.Name() != (JSONCodec{}).Name() {
+		return nil, fmt.Errorf("kvstore: NewWithCodec requires a JSON KeyCodec on table %s; got %q, and range/prefix queries and watch matching still build SQL bounds assuming JSON-encoded keys", name, keyCodec.Name())
+	}
+
+	store := New[T1, T2](name, opts...)
+	store.keyCodec = keyCodec
+	store.valueCodec = valueCodec
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := store.ensureCodecMeta(ctx); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
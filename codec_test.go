@@ -0,0 +1,95 @@
+package kvstore_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/xtdlib/kvstore"
+)
+
+func TestNewAtWithCodecRawBytesRoundTrips(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_codec_raw.db")
+
+	store, err := kvstore.NewAtWithCodec[string, []byte](dbPath, "test_codec_raw", kvstore.JSONCodec{}, kvstore.RawBytesCodec{})
+	if err != nil {
+		t.Fatalf("NewAtWithCodec failed: %v", err)
+	}
+
+	payload := []byte{0x00, 0xff, 'h', 'i', 0x00}
+	store.Set("blob", payload)
+
+	got := store.Get("blob")
+	if string(got) != string(payload) {
+		t.Fatalf("Expected %v, got %v", payload, got)
+	}
+}
+
+func TestNewAtWithCodecGobRoundTrips(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_codec_gob.db")
+
+	store, err := kvstore.NewAtWithCodec[string, string](dbPath, "test_codec_gob", kvstore.JSONCodec{}, kvstore.GobCodec{})
+	if err != nil {
+		t.Fatalf("NewAtWithCodec failed: %v", err)
+	}
+
+	store.Set("greeting", "hello")
+	if got := store.Get("greeting"); got != "hello" {
+		t.Fatalf("Expected hello, got %s", got)
+	}
+}
+
+func TestNewAtWithCodecGobValuesSurviveIteration(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_codec_gob_iter.db")
+
+	store, err := kvstore.NewAtWithCodec[string, string](dbPath, "test_codec_gob_iter", kvstore.JSONCodec{}, kvstore.GobCodec{})
+	if err != nil {
+		t.Fatalf("NewAtWithCodec failed: %v", err)
+	}
+
+	store.Set("a", "alpha")
+	store.Set("b", "beta")
+
+	seen := map[string]string{}
+	store.ForEach(func(k, v string) bool {
+		seen[k] = v
+		return true
+	})
+	if seen["a"] != "alpha" || seen["b"] != "beta" {
+		t.Fatalf("Expected ForEach to decode gob-encoded values, got %v", seen)
+	}
+
+	seen = map[string]string{}
+	for k, v := range store.All() {
+		seen[k] = v
+	}
+	if seen["a"] != "alpha" || seen["b"] != "beta" {
+		t.Fatalf("Expected All to decode gob-encoded values, got %v", seen)
+	}
+}
+
+func TestNewAtWithCodecRejectsNonJSONKeyCodec(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_codec_nonjson_key.db")
+
+	if _, err := kvstore.NewAtWithCodec[string, string](dbPath, "test_codec_nonjson_key", kvstore.GobCodec{}, kvstore.JSONCodec{}); err == nil {
+		t.Fatal("Expected NewAtWithCodec to reject a non-JSON KeyCodec")
+	}
+}
+
+func TestNewAtWithCodecRejectsMismatchOnReopen(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_codec_mismatch.db")
+
+	_, err := kvstore.NewAtWithCodec[string, string](dbPath, "test_codec_mismatch", kvstore.JSONCodec{}, kvstore.GobCodec{})
+	if err != nil {
+		t.Fatalf("First open failed: %v", err)
+	}
+
+	_, err = kvstore.NewAtWithCodec[string, string](dbPath, "test_codec_mismatch", kvstore.JSONCodec{}, kvstore.JSONCodec{})
+	if err == nil {
+		t.Fatal("Expected reopening with a mismatched value codec to fail")
+	}
+}
@@ -0,0 +1,246 @@
+package kvstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// ErrCompacted is returned by History and WatchFrom when the requested
+// revision is older than the store's compaction floor, so the caller must
+// resync from scratch (e.g. via All/Iter) instead of replaying history.
+var ErrCompacted = errors.New("kvstore: revision has been compacted")
+
+// HistoryEntry is one row of a key's change log, as recorded in the
+// store's revision history table.
+type HistoryEntry[T1 comparable, T2 comparable] struct {
+	Rev       int64
+	Key       T1
+	PrevValue T2
+	NewValue  T2
+	Action    Action
+	Timestamp time.Time
+}
+
+// HistoryRetention bounds how large a store's history table is allowed to
+// grow. Either field may be left zero to disable that bound; both may be
+// set together, in which case whichever is stricter wins.
+type HistoryRetention struct {
+	MaxEntries int
+	MaxAge     time.Duration
+}
+
+// SetHistoryRetention configures the retention window applied after every
+// write. It is not retroactive: call Compact to trim existing history.
+func (s *KV[T1, T2]) SetHistoryRetention(r HistoryRetention) {
+	s.retention = r
+}
+
+func (s *KV[T1, T2]) historyTable() string {
+	return s.table + "_history"
+}
+
+func (s *KV[T1, T2]) initHistory(ctx context.Context) error {
+	createSQL := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		rev INTEGER PRIMARY KEY AUTOINCREMENT,
+		key TEXT,
+		prev_value TEXT,
+		new_value TEXT,
+		action INTEGER,
+		ts INTEGER
+	)`, s.historyTable())
+	_, err := s.db.ExecContext(ctx, createSQL)
+	return err
+}
+
+// appendHistory records one change to key and returns the revision
+// assigned to it. Failures are non-fatal to the caller's write: the
+// caller decides whether to surface them.
+func (s *KV[T1, T2]) appendHistory(key T1, prevValue, newValue T2, action Action) (int64, error) {
+	keyBytes, err := json.Marshal(key)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal key: %w", err)
+	}
+	prevBytes, err := json.Marshal(prevValue)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal prev value: %w", err)
+	}
+	newBytes, err := json.Marshal(newValue)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal new value: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	insertSQL := fmt.Sprintf(
+		"INSERT INTO %s (key, prev_value, new_value, action, ts) VALUES (?, ?, ?, ?, ?)",
+		s.historyTable())
+	result, err := s.db.ExecContext(ctx, insertSQL,
+		string(keyBytes), string(prevBytes), string(newBytes), int(action), time.Now().UnixNano())
+	if err != nil {
+		return 0, err
+	}
+	rev, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	s.trimHistory(ctx)
+
+	return rev, nil
+}
+
+// trimHistory enforces s.retention, best-effort.
+func (s *KV[T1, T2]) trimHistory(ctx context.Context) {
+	if s.retention.MaxEntries > 0 {
+		deleteSQL := fmt.Sprintf(
+			"DELETE FROM %s WHERE rev <= (SELECT COALESCE(MAX(rev), 0) - ? FROM %s)",
+			s.historyTable(), s.historyTable())
+		s.db.ExecContext(ctx, deleteSQL, s.retention.MaxEntries)
+	}
+	if s.retention.MaxAge > 0 {
+		cutoff := time.Now().Add(-s.retention.MaxAge).UnixNano()
+		deleteSQL := fmt.Sprintf("DELETE FROM %s WHERE ts < ?", s.historyTable())
+		s.db.ExecContext(ctx, deleteSQL, cutoff)
+	}
+}
+
+// History returns the ordered change log for key with revision >= fromRev,
+// oldest first. limit <= 0 means no limit.
+func (s *KV[T1, T2]) History(key T1, fromRev int64, limit int) ([]HistoryEntry[T1, T2], error) {
+	if err := s.requireDefaultBackend("History"); err != nil {
+		return nil, err
+	}
+	if fromRev < atomic.LoadInt64(&s.compactedRev) {
+		return nil, ErrCompacted
+	}
+
+	keyBytes, err := json.Marshal(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal key: %w", err)
+	}
+
+	query := fmt.Sprintf(
+		"SELECT rev, prev_value, new_value, action, ts FROM %s WHERE key = ? AND rev >= ? ORDER BY rev",
+		s.historyTable())
+	args := []any{string(keyBytes), fromRev}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []HistoryEntry[T1, T2]
+	for rows.Next() {
+		var rev int64
+		var prevStr, newStr string
+		var action int
+		var tsNano int64
+		if err := rows.Scan(&rev, &prevStr, &newStr, &action, &tsNano); err != nil {
+			return nil, err
+		}
+
+		entry := HistoryEntry[T1, T2]{
+			Rev:       rev,
+			Key:       key,
+			Action:    Action(action),
+			Timestamp: time.Unix(0, tsNano),
+		}
+		if err := json.Unmarshal([]byte(prevStr), &entry.PrevValue); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal prev value: %w", err)
+		}
+		if err := json.Unmarshal([]byte(newStr), &entry.NewValue); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal new value: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// Compact discards history at or below rev. Any later History or WatchFrom
+// call with fromRev < rev returns ErrCompacted; one with fromRev == rev
+// succeeds without the discarded entry at rev.
+func (s *KV[T1, T2]) Compact(rev int64) error {
+	if err := s.requireDefaultBackend("Compact"); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	deleteSQL := fmt.Sprintf("DELETE FROM %s WHERE rev <= ?", s.historyTable())
+	if _, err := s.db.ExecContext(ctx, deleteSQL, rev); err != nil {
+		return err
+	}
+	atomic.StoreInt64(&s.compactedRev, rev)
+	return nil
+}
+
+// toEvent renders a history row as the WatchEvent it originally produced,
+// for replay by WatchFrom.
+func (e HistoryEntry[T1, T2]) toEvent() WatchEvent[T1, T2] {
+	event := WatchEvent[T1, T2]{
+		Key:       e.Key,
+		Value:     e.NewValue,
+		OldValue:  e.PrevValue,
+		PrevValue: e.PrevValue,
+		Action:    e.Action,
+		Rev:       e.Rev,
+		Exists:    e.Action != Create,
+	}
+	if e.Action == Delete {
+		event.Type = WatchEventDelete
+	} else {
+		event.Type = WatchEventSet
+	}
+	return event
+}
+
+// WatchFrom replays every historical change to key with revision >=
+// fromRev, oldest first, then seamlessly continues with live events from
+// the broadcaster so no update is missed or duplicated across the
+// replay/live boundary. It returns ErrCompacted if fromRev is older than
+// the compaction floor.
+func (s *KV[T1, T2]) WatchFrom(key T1, fromRev int64) (<-chan WatchEvent[T1, T2], CancelFunc, error) {
+	if fromRev < atomic.LoadInt64(&s.compactedRev) {
+		return nil, nil, ErrCompacted
+	}
+
+	// Subscribe before reading history, so a write that happens between
+	// the two can never be missed entirely.
+	cfg := defaultWatchConfig()
+	live, cancel := s.broadcaster.subscribe(nil, cfg, exactMatcher(keyString(key)))
+
+	out := make(chan WatchEvent[T1, T2], cfg.bufSize)
+	go func() {
+		defer close(out)
+
+		lastRev := fromRev - 1
+		if entries, err := s.History(key, fromRev, 0); err == nil {
+			for _, entry := range entries {
+				out <- entry.toEvent()
+				lastRev = entry.Rev
+			}
+		}
+
+		// Live events carry the same revisions; skip anything already
+		// delivered by the replay above to avoid duplicates at the seam.
+		for event := range live {
+			if event.Rev != 0 && event.Rev <= lastRev {
+				continue
+			}
+			out <- event
+		}
+	}()
+
+	return out, cancel, nil
+}
@@ -0,0 +1,161 @@
+package kvstore_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/xtdlib/kvstore"
+)
+
+func TestHistoryAndCompact(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_history.db")
+
+	store, err := kvstore.NewAt[string, string](dbPath, "test_history")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	store.Set("key1", "value1")
+	store.Set("key1", "value2")
+	store.Delete("key1")
+
+	entries, err := store.History("key1", 0, 0)
+	if err != nil {
+		t.Fatalf("History failed: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 history entries, got %d", len(entries))
+	}
+	if entries[0].Action != kvstore.Create || entries[0].NewValue != "value1" {
+		t.Errorf("Unexpected first entry: %+v", entries[0])
+	}
+	if entries[1].Action != kvstore.Update || entries[1].PrevValue != "value1" || entries[1].NewValue != "value2" {
+		t.Errorf("Unexpected second entry: %+v", entries[1])
+	}
+	if entries[2].Action != kvstore.Delete || entries[2].PrevValue != "value2" {
+		t.Errorf("Unexpected third entry: %+v", entries[2])
+	}
+
+	// Compacting past the last entry means further History/WatchFrom
+	// calls starting at or before that revision fail loudly.
+	if err := store.Compact(entries[2].Rev); err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+	if _, err := store.History("key1", 0, 0); err != kvstore.ErrCompacted {
+		t.Fatalf("Expected ErrCompacted, got %v", err)
+	}
+}
+
+func TestWatchFromReplaysThenLive(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_watch_from.db")
+
+	store, err := kvstore.NewAt[string, string](dbPath, "test_watch_from")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	store.Set("key1", "value1")
+	store.Set("key1", "value2")
+
+	eventCh, cancel, err := store.WatchFrom("key1", 1)
+	if err != nil {
+		t.Fatalf("WatchFrom failed: %v", err)
+	}
+	defer cancel()
+
+	// First two events come from the replayed history.
+	for _, want := range []string{"value1", "value2"} {
+		select {
+		case event := <-eventCh:
+			if event.Value != want {
+				t.Fatalf("Expected replayed value %s, got %v", want, event.Value)
+			}
+		case <-time.After(1 * time.Second):
+			t.Fatalf("Timeout waiting for replayed event %s", want)
+		}
+	}
+
+	// Live writes after replay arrive on the same channel without a gap.
+	store.Set("key1", "value3")
+	select {
+	case event := <-eventCh:
+		if event.Value != "value3" {
+			t.Fatalf("Expected live value3, got %v", event.Value)
+		}
+	case <-time.After(1 * time.Second):
+		t.Fatal("Timeout waiting for live event")
+	}
+}
+
+func TestWatchPrefixFromRevReplaysThenLive(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_watch_prefix_from_rev.db")
+
+	store, err := kvstore.NewAt[string, string](dbPath, "test_watch_prefix_from_rev")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	store.Set("user:1", "alice")
+	store.Set("user:2", "bob")
+	store.Set("other:1", "ignored")
+
+	eventCh, cancel, err := store.WatchPrefixFromRev("user:", 1)
+	if err != nil {
+		t.Fatalf("WatchPrefixFromRev failed: %v", err)
+	}
+	defer cancel()
+
+	seen := make(map[string]string)
+	for i := 0; i < 2; i++ {
+		select {
+		case event := <-eventCh:
+			seen[event.Key] = event.Value
+		case <-time.After(1 * time.Second):
+			t.Fatalf("Timeout waiting for replayed event %d", i)
+		}
+	}
+	if seen["user:1"] != "alice" || seen["user:2"] != "bob" {
+		t.Fatalf("Unexpected replayed events: %v", seen)
+	}
+
+	store.Set("user:3", "carol")
+	select {
+	case event := <-eventCh:
+		if event.Key != "user:3" || event.Value != "carol" {
+			t.Fatalf("Expected live user:3=carol, got %+v", event)
+		}
+	case <-time.After(1 * time.Second):
+		t.Fatal("Timeout waiting for live event")
+	}
+}
+
+func TestWatchFromRevIsWatchFrom(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_watch_from_rev.db")
+
+	store, err := kvstore.NewAt[string, string](dbPath, "test_watch_from_rev")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	store.Set("key1", "value1")
+
+	eventCh, cancel, err := store.WatchFromRev("key1", 0)
+	if err != nil {
+		t.Fatalf("WatchFromRev failed: %v", err)
+	}
+	defer cancel()
+
+	select {
+	case event := <-eventCh:
+		if event.Value != "value1" {
+			t.Fatalf("Expected replayed value1, got %v", event.Value)
+		}
+	case <-time.After(1 * time.Second):
+		t.Fatal("Timeout waiting for replayed event")
+	}
+}
@@ -0,0 +1,405 @@
+package kvstore
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"time"
+)
+
+// indexInfo describes one secondary index registered via AddIndex: its
+// companion table and the function that derives an indexed key from a
+// row's primary key/value.
+type indexInfo[T1 comparable, T2 comparable] struct {
+	name    string
+	table   string
+	extract func(T1, T2) []byte
+}
+
+func (s *KV[T1, T2]) indexTable(name string) string {
+	return fmt.Sprintf("%s__idx_%s", s.table, name)
+}
+
+// index returns the registered index named name, if any.
+func (s *KV[T1, T2]) index(name string) (*indexInfo[T1, T2], bool) {
+	s.indexMu.RLock()
+	defer s.indexMu.RUnlock()
+	idx, ok := s.indexes[name]
+	return idx, ok
+}
+
+// hasIndexes reports whether any secondary index is registered, so
+// TrySet/TryDelete/TryClear can skip the extra transaction entirely on
+// stores that don't use them.
+func (s *KV[T1, T2]) hasIndexes() bool {
+	s.indexMu.RLock()
+	defer s.indexMu.RUnlock()
+	return len(s.indexes) > 0
+}
+
+func (s *KV[T1, T2]) indexList() []*indexInfo[T1, T2] {
+	s.indexMu.RLock()
+	defer s.indexMu.RUnlock()
+	list := make([]*indexInfo[T1, T2], 0, len(s.indexes))
+	for _, idx := range s.indexes {
+		list = append(list, idx)
+	}
+	return list
+}
+
+// AddIndex creates a secondary index named name, keyed by whatever bytes
+// extract derives from each row's key/value, and backfills it from every
+// row already in the store inside a single transaction. Once registered,
+// every write path that goes through TrySet/TryDelete/TryClear,
+// CompareAndSwap/CreateOnly, SetTTL/SetWithExpiry, DeleteRange/DeletePrefix,
+// or a Transaction/TxnOps-driven Tx.Set/Tx.Delete/Tx.Clear keeps the
+// index's companion table "<table>__idx_<name>" synchronized with the
+// primary table as part of the same SQL transaction as the write, so a
+// partial failure can never leave the index and table disagreeing. The
+// background TTL reaper and lease expiry still bypass this (see reapExpired
+// and lease.go's revoke), so an index is not yet safe to combine with TTLs
+// or leases.
+func (s *KV[T1, T2]) AddIndex(name string, extract func(T1, T2) []byte) error {
+	if err := s.requireDefaultBackend("AddIndex"); err != nil {
+		return err
+	}
+
+	s.indexMu.Lock()
+	if s.indexes == nil {
+		s.indexes = make(map[string]*indexInfo[T1, T2])
+	}
+	if _, exists := s.indexes[name]; exists {
+		s.indexMu.Unlock()
+		return fmt.Errorf("kvstore: index %q already exists on table %s", name, s.table)
+	}
+	s.indexMu.Unlock()
+
+	idx := &indexInfo[T1, T2]{
+		name:    name,
+		table:   s.indexTable(name),
+		extract: extract,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	createSQL := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		indexed_key BLOB,
+		primary_key BLOB
+	)`, idx.table)
+	if _, err := s.db.ExecContext(ctx, createSQL); err != nil {
+		return err
+	}
+	createIdxSQL := fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s_key ON %s (indexed_key)", idx.table, idx.table)
+	if _, err := s.db.ExecContext(ctx, createIdxSQL); err != nil {
+		return err
+	}
+
+	if err := s.backfillIndex(ctx, idx); err != nil {
+		return err
+	}
+
+	s.indexMu.Lock()
+	s.indexes[name] = idx
+	s.indexMu.Unlock()
+	return nil
+}
+
+// backfillIndex (re)populates idx.table from every row currently in
+// s.table, inside one transaction, so AddIndex on a non-empty store
+// starts from a table and index that agree.
+func (s *KV[T1, T2]) backfillIndex(ctx context.Context, idx *indexInfo[T1, T2]) error {
+	sqlTx, err := beginImmediate(ctx, s.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if _, err := sqlTx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s", idx.table)); err != nil {
+		sqlTx.Rollback()
+		return err
+	}
+
+	rows, err := sqlTx.QueryContext(ctx, fmt.Sprintf("SELECT key, value FROM %s", s.table))
+	if err != nil {
+		sqlTx.Rollback()
+		return err
+	}
+
+	type row struct {
+		keyStr     string
+		valueBytes []byte
+	}
+	var allRows []row
+	for rows.Next() {
+		var r row
+		if err := rows.Scan(&r.keyStr, &r.valueBytes); err != nil {
+			rows.Close()
+			sqlTx.Rollback()
+			return err
+		}
+		allRows = append(allRows, r)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		sqlTx.Rollback()
+		return err
+	}
+	rows.Close()
+
+	insertSQL := fmt.Sprintf("INSERT INTO %s (indexed_key, primary_key) VALUES (?, ?)", idx.table)
+	for _, r := range allRows {
+		var k T1
+		var v T2
+		if err := s.kc().Unmarshal([]byte(r.keyStr), &k); err != nil {
+			sqlTx.Rollback()
+			return fmt.Errorf("failed to unmarshal key: %w", err)
+		}
+		if err := s.vc().Unmarshal(r.valueBytes, &v); err != nil {
+			sqlTx.Rollback()
+			return fmt.Errorf("failed to unmarshal value: %w", err)
+		}
+		if _, err := sqlTx.ExecContext(ctx, insertSQL, idx.extract(k, v), []byte(r.keyStr)); err != nil {
+			sqlTx.Rollback()
+			return err
+		}
+	}
+
+	return sqlTx.Commit()
+}
+
+// syncIndexSet replaces key's entry in every registered index's companion
+// table with one derived from key/value, using tx's own *sql.Tx so the
+// index write commits or rolls back together with whatever primary-table
+// write it accompanies (Tx.Set, or an index-aware CompareAndSwap/CreateOnly
+// built on top of it).
+func (tx *Tx[T1, T2]) syncIndexSet(key T1, value T2, keyBytes []byte) error {
+	for _, idx := range tx.store.indexList() {
+		if _, err := tx.tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE primary_key = ?", idx.table), keyBytes); err != nil {
+			return err
+		}
+		insertIdxSQL := fmt.Sprintf("INSERT INTO %s (indexed_key, primary_key) VALUES (?, ?)", idx.table)
+		if _, err := tx.tx.Exec(insertIdxSQL, idx.extract(key, value), keyBytes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncIndexDelete removes keyBytes's entry from every registered index's
+// companion table, using tx's own *sql.Tx. It is harmless to call even when
+// keyBytes never had an index entry (DeleteRange/DeletePrefix sweep rows
+// that may or may not exist).
+func (tx *Tx[T1, T2]) syncIndexDelete(keyBytes []byte) error {
+	for _, idx := range tx.store.indexList() {
+		if _, err := tx.tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE primary_key = ?", idx.table), keyBytes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncIndexClear empties every registered index's companion table, using
+// tx's own *sql.Tx.
+func (tx *Tx[T1, T2]) syncIndexClear() error {
+	for _, idx := range tx.store.indexList() {
+		if _, err := tx.tx.Exec(fmt.Sprintf("DELETE FROM %s", idx.table)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setWithExpiryAndIndexes performs the primary INSERT OR REPLACE (with an
+// expiry) plus every registered index's upsert inside one transaction,
+// mirroring setWithIndexes for SetTTL/SetWithExpiry.
+func (s *KV[T1, T2]) setWithExpiryAndIndexes(ctx context.Context, key T1, value T2, keyBytes, valueBytes []byte, expiryNano int64) error {
+	sqlTx, err := beginImmediate(ctx, s.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	insertSQL := fmt.Sprintf("INSERT OR REPLACE INTO %s (key, value, expiry) VALUES (?, ?, ?)", s.table)
+	if _, err := sqlTx.ExecContext(ctx, insertSQL, string(keyBytes), string(valueBytes), expiryNano); err != nil {
+		sqlTx.Rollback()
+		return err
+	}
+
+	for _, idx := range s.indexList() {
+		if _, err := sqlTx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE primary_key = ?", idx.table), keyBytes); err != nil {
+			sqlTx.Rollback()
+			return err
+		}
+		insertIdxSQL := fmt.Sprintf("INSERT INTO %s (indexed_key, primary_key) VALUES (?, ?)", idx.table)
+		if _, err := sqlTx.ExecContext(ctx, insertIdxSQL, idx.extract(key, value), keyBytes); err != nil {
+			sqlTx.Rollback()
+			return err
+		}
+	}
+
+	return sqlTx.Commit()
+}
+
+// RangeIndex returns an iterator, for use with Go 1.23+ range-over-func,
+// over every row whose indexName-derived key k satisfies lo <= k < hi,
+// ordered by indexed key. It is named RangeIndex rather than Range to
+// avoid colliding with the existing primary-key Range(lo, hi T1).
+func (s *KV[T1, T2]) RangeIndex(indexName string, lo, hi []byte) iter.Seq2[T1, T2] {
+	return func(yield func(T1, T2) bool) {
+		idx, ok := s.index(indexName)
+		if !ok {
+			return
+		}
+
+		query := fmt.Sprintf(
+			"SELECT %s.key, %s.value FROM %s JOIN %s ON %s.primary_key = CAST(%s.key AS BLOB) "+
+				"WHERE %s.indexed_key >= ? AND %s.indexed_key < ? ORDER BY %s.indexed_key",
+			s.table, s.table, s.table, idx.table, idx.table, s.table, idx.table, idx.table, idx.table)
+		rows, err := s.db.QueryContext(context.Background(), query, lo, hi)
+		if err != nil {
+			return
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var keyStr string
+			var valueBytes []byte
+			if err := rows.Scan(&keyStr, &valueBytes); err != nil {
+				return
+			}
+			var k T1
+			var v T2
+			if err := s.kc().Unmarshal([]byte(keyStr), &k); err != nil {
+				return
+			}
+			if err := s.vc().Unmarshal(valueBytes, &v); err != nil {
+				return
+			}
+			if !yield(k, v) {
+				return
+			}
+		}
+	}
+}
+
+// Lookup returns every value whose indexName-derived key equals
+// indexedKey, in no particular order.
+func (s *KV[T1, T2]) Lookup(indexName string, indexedKey []byte) ([]T2, error) {
+	idx, ok := s.index(indexName)
+	if !ok {
+		return nil, fmt.Errorf("kvstore: no such index %q on table %s", indexName, s.table)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := fmt.Sprintf(
+		"SELECT %s.value FROM %s JOIN %s ON %s.primary_key = CAST(%s.key AS BLOB) WHERE %s.indexed_key = ?",
+		s.table, s.table, idx.table, idx.table, s.table, idx.table)
+	rows, err := s.db.QueryContext(ctx, query, indexedKey)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []T2
+	for rows.Next() {
+		var valueBytes []byte
+		if err := rows.Scan(&valueBytes); err != nil {
+			return nil, err
+		}
+		var v T2
+		if err := s.vc().Unmarshal(valueBytes, &v); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal value: %w", err)
+		}
+		values = append(values, v)
+	}
+	return values, rows.Err()
+}
+
+// setWithIndexes performs the primary INSERT OR REPLACE plus every
+// registered index's upsert inside one transaction, so a mid-write
+// failure can never leave an index pointing at a stale or missing row.
+func (s *KV[T1, T2]) setWithIndexes(ctx context.Context, key T1, value T2, keyBytes, valueBytes []byte) error {
+	sqlTx, err := beginImmediate(ctx, s.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	insertSQL := fmt.Sprintf("INSERT OR REPLACE INTO %s (key, value) VALUES (?, ?)", s.table)
+	if _, err := sqlTx.ExecContext(ctx, insertSQL, string(keyBytes), valueBytes); err != nil {
+		sqlTx.Rollback()
+		return err
+	}
+
+	for _, idx := range s.indexList() {
+		if _, err := sqlTx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE primary_key = ?", idx.table), keyBytes); err != nil {
+			sqlTx.Rollback()
+			return err
+		}
+		insertIdxSQL := fmt.Sprintf("INSERT INTO %s (indexed_key, primary_key) VALUES (?, ?)", idx.table)
+		if _, err := sqlTx.ExecContext(ctx, insertIdxSQL, idx.extract(key, value), keyBytes); err != nil {
+			sqlTx.Rollback()
+			return err
+		}
+	}
+
+	return sqlTx.Commit()
+}
+
+// deleteWithIndexes removes key from the primary table and every
+// registered index's companion table inside one transaction, and
+// reports how many primary rows were affected (0 or 1).
+func (s *KV[T1, T2]) deleteWithIndexes(ctx context.Context, keyBytes []byte) (int64, error) {
+	sqlTx, err := beginImmediate(ctx, s.db)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	deleteSQL := fmt.Sprintf("DELETE FROM %s WHERE key = ?", s.table)
+	result, err := sqlTx.ExecContext(ctx, deleteSQL, string(keyBytes))
+	if err != nil {
+		sqlTx.Rollback()
+		return 0, err
+	}
+
+	for _, idx := range s.indexList() {
+		if _, err := sqlTx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE primary_key = ?", idx.table), keyBytes); err != nil {
+			sqlTx.Rollback()
+			return 0, err
+		}
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		sqlTx.Rollback()
+		return 0, err
+	}
+
+	if err := sqlTx.Commit(); err != nil {
+		return 0, err
+	}
+	return rowsAffected, nil
+}
+
+// clearWithIndexes empties the primary table and every registered
+// index's companion table inside one transaction.
+func (s *KV[T1, T2]) clearWithIndexes(ctx context.Context) error {
+	sqlTx, err := beginImmediate(ctx, s.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if _, err := sqlTx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s", s.table)); err != nil {
+		sqlTx.Rollback()
+		return err
+	}
+
+	for _, idx := range s.indexList() {
+		if _, err := sqlTx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s", idx.table)); err != nil {
+			sqlTx.Rollback()
+			return err
+		}
+	}
+
+	return sqlTx.Commit()
+}
@@ -0,0 +1,238 @@
+package kvstore_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/xtdlib/kvstore"
+)
+
+type indexedUser struct {
+	Name string
+	Age  int
+}
+
+func TestAddIndexBackfillsAndLooksUp(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_index_backfill.db")
+
+	store, err := kvstore.NewAt[string, indexedUser](dbPath, "test_index_backfill")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	store.Set("u1", indexedUser{Name: "alice", Age: 30})
+	store.Set("u2", indexedUser{Name: "bob", Age: 30})
+	store.Set("u3", indexedUser{Name: "carol", Age: 40})
+
+	err = store.AddIndex("age", func(_ string, v indexedUser) []byte {
+		return []byte(fmt.Sprintf("%04d", v.Age))
+	})
+	if err != nil {
+		t.Fatalf("AddIndex failed: %v", err)
+	}
+
+	got, err := store.Lookup("age", []byte("0030"))
+	if err != nil {
+		t.Fatalf("Lookup failed: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("Expected 2 users aged 30, got %d", len(got))
+	}
+}
+
+func TestAddIndexStaysInSyncWithWrites(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_index_sync.db")
+
+	store, err := kvstore.NewAt[string, indexedUser](dbPath, "test_index_sync")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	err = store.AddIndex("age", func(_ string, v indexedUser) []byte {
+		return []byte(fmt.Sprintf("%04d", v.Age))
+	})
+	if err != nil {
+		t.Fatalf("AddIndex failed: %v", err)
+	}
+
+	store.Set("u1", indexedUser{Name: "alice", Age: 30})
+	store.Set("u2", indexedUser{Name: "bob", Age: 30})
+
+	got, err := store.Lookup("age", []byte("0030"))
+	if err != nil {
+		t.Fatalf("Lookup failed: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("Expected 2 users aged 30, got %d", len(got))
+	}
+
+	store.Delete("u1")
+	got, err = store.Lookup("age", []byte("0030"))
+	if err != nil {
+		t.Fatalf("Lookup after delete failed: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("Expected 1 user aged 30 after delete, got %d", len(got))
+	}
+
+	store.Set("u2", indexedUser{Name: "bob", Age: 40})
+	got, err = store.Lookup("age", []byte("0030"))
+	if err != nil {
+		t.Fatalf("Lookup after re-set failed: %v", err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("Expected 0 users aged 30 after re-set, got %d", len(got))
+	}
+
+	got, err = store.Lookup("age", []byte("0040"))
+	if err != nil {
+		t.Fatalf("Lookup after re-set failed: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("Expected 1 user aged 40 after re-set, got %d", len(got))
+	}
+}
+
+func TestAddIndexStaysInSyncWithAtomicWrites(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_index_sync_atomic.db")
+
+	store, err := kvstore.NewAt[string, indexedUser](dbPath, "test_index_sync_atomic")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	if err := store.AddIndex("age", func(_ string, v indexedUser) []byte {
+		return []byte(fmt.Sprintf("%04d", v.Age))
+	}); err != nil {
+		t.Fatalf("AddIndex failed: %v", err)
+	}
+
+	if created, err := store.CreateOnly("u1", indexedUser{Name: "alice", Age: 30}); err != nil || !created {
+		t.Fatalf("CreateOnly failed: created=%v, err=%v", created, err)
+	}
+	got, err := store.Lookup("age", []byte("0030"))
+	if err != nil || len(got) != 1 {
+		t.Fatalf("Expected CreateOnly to be reflected in the index, got %d, err=%v", len(got), err)
+	}
+
+	swapped, err := store.CompareAndSwap("u1", indexedUser{Name: "alice", Age: 30}, indexedUser{Name: "alice", Age: 40})
+	if err != nil || !swapped {
+		t.Fatalf("CompareAndSwap failed: swapped=%v, err=%v", swapped, err)
+	}
+	if got, err := store.Lookup("age", []byte("0030")); err != nil || len(got) != 0 {
+		t.Fatalf("Expected the stale age-30 index entry to be gone after CompareAndSwap, got %d, err=%v", len(got), err)
+	}
+	if got, err := store.Lookup("age", []byte("0040")); err != nil || len(got) != 1 {
+		t.Fatalf("Expected CompareAndSwap to be reflected in the index, got %d, err=%v", len(got), err)
+	}
+}
+
+func TestAddIndexStaysInSyncWithSetTTL(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_index_sync_ttl.db")
+
+	store, err := kvstore.NewAt[string, indexedUser](dbPath, "test_index_sync_ttl")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	if err := store.AddIndex("age", func(_ string, v indexedUser) []byte {
+		return []byte(fmt.Sprintf("%04d", v.Age))
+	}); err != nil {
+		t.Fatalf("AddIndex failed: %v", err)
+	}
+
+	if err := store.SetTTL("u1", indexedUser{Name: "alice", Age: 30}, time.Hour); err != nil {
+		t.Fatalf("SetTTL failed: %v", err)
+	}
+	got, err := store.Lookup("age", []byte("0030"))
+	if err != nil || len(got) != 1 {
+		t.Fatalf("Expected SetTTL to be reflected in the index, got %d, err=%v", len(got), err)
+	}
+}
+
+func TestAddIndexStaysInSyncWithDeleteRangeAndTx(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_index_sync_range.db")
+
+	store, err := kvstore.NewAt[string, indexedUser](dbPath, "test_index_sync_range")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	if err := store.AddIndex("age", func(_ string, v indexedUser) []byte {
+		return []byte(fmt.Sprintf("%04d", v.Age))
+	}); err != nil {
+		t.Fatalf("AddIndex failed: %v", err)
+	}
+
+	store.Set("u1", indexedUser{Name: "alice", Age: 30})
+	store.Set("u2", indexedUser{Name: "bob", Age: 30})
+	store.Set("u3", indexedUser{Name: "carol", Age: 40})
+
+	if _, err := store.DeleteRange("u1", "u3"); err != nil {
+		t.Fatalf("DeleteRange failed: %v", err)
+	}
+	if got, err := store.Lookup("age", []byte("0030")); err != nil || len(got) != 0 {
+		t.Fatalf("Expected DeleteRange to remove the age-30 index entries, got %d, err=%v", len(got), err)
+	}
+	if got, err := store.Lookup("age", []byte("0040")); err != nil || len(got) != 1 {
+		t.Fatalf("Expected u3 (outside the range) to remain indexed, got %d, err=%v", len(got), err)
+	}
+
+	err = store.Transaction(func(tx *kvstore.Tx[string, indexedUser]) error {
+		if err := tx.Set("u4", indexedUser{Name: "dan", Age: 50}); err != nil {
+			return err
+		}
+		return tx.Delete("u3")
+	})
+	if err != nil {
+		t.Fatalf("Transaction failed: %v", err)
+	}
+	if got, err := store.Lookup("age", []byte("0050")); err != nil || len(got) != 1 {
+		t.Fatalf("Expected Tx.Set to be reflected in the index, got %d, err=%v", len(got), err)
+	}
+	if got, err := store.Lookup("age", []byte("0040")); err != nil || len(got) != 0 {
+		t.Fatalf("Expected Tx.Delete to remove u3's index entry, got %d, err=%v", len(got), err)
+	}
+
+	if err := store.Transaction(func(tx *kvstore.Tx[string, indexedUser]) error {
+		return tx.Clear()
+	}); err != nil {
+		t.Fatalf("Transaction failed: %v", err)
+	}
+	if got, err := store.Lookup("age", []byte("0050")); err != nil || len(got) != 0 {
+		t.Fatalf("Expected Tx.Clear to empty the index, got %d, err=%v", len(got), err)
+	}
+}
+
+func TestRangeIndex(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_index_range.db")
+
+	store, err := kvstore.NewAt[string, indexedUser](dbPath, "test_index_range")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	err = store.AddIndex("age", func(_ string, v indexedUser) []byte {
+		return []byte(fmt.Sprintf("%04d", v.Age))
+	})
+	if err != nil {
+		t.Fatalf("AddIndex failed: %v", err)
+	}
+
+	store.Set("u1", indexedUser{Name: "alice", Age: 20})
+	store.Set("u2", indexedUser{Name: "bob", Age: 30})
+	store.Set("u3", indexedUser{Name: "carol", Age: 40})
+
+	count := 0
+	for range store.RangeIndex("age", []byte("0025"), []byte("0045")) {
+		count++
+	}
+	if count != 2 {
+		t.Fatalf("Expected 2 users in [25,45), got %d", count)
+	}
+}
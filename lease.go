@@ -0,0 +1,295 @@
+package kvstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// LeaseID identifies a lease granted by Grant.
+type LeaseID int64
+
+// leaseManager owns the sibling tables and background reaper for one
+// store's leases. It is created lazily, the same way lockStore is, so a
+// store that never calls Grant never pays for it.
+type leaseManager[T1 comparable, T2 comparable] struct {
+	store      *KV[T1, T2]
+	leaseTable string
+	keysTable  string
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+func (s *KV[T1, T2]) leases() (*leaseManager[T1, T2], error) {
+	if err := s.requireDefaultBackend("Grant/SetWithLease/KeepAlive/Revoke"); err != nil {
+		return nil, err
+	}
+
+	s.leaseOnce.Do(func() {
+		lm := &leaseManager[T1, T2]{
+			store:      s,
+			leaseTable: s.table + "_leases",
+			keysTable:  s.table + "_lease_keys",
+			stop:       make(chan struct{}),
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+
+		createLeases := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			lease_id INTEGER PRIMARY KEY AUTOINCREMENT,
+			ttl_ns INTEGER,
+			expires_at_ns INTEGER
+		)`, lm.leaseTable)
+		if _, err := s.db.ExecContext(ctx, createLeases); err != nil {
+			s.leaseErr = err
+			return
+		}
+
+		createKeys := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			lease_id INTEGER,
+			key TEXT PRIMARY KEY
+		)`, lm.keysTable)
+		if _, err := s.db.ExecContext(ctx, createKeys); err != nil {
+			s.leaseErr = err
+			return
+		}
+
+		s.leaseMgr = lm
+		// Already-expired leases (e.g. the process was down past their
+		// TTL) are revoked on the reaper's first tick below, rather than
+		// requiring a separate reschedule pass on open.
+		go lm.run()
+	})
+	if s.leaseErr != nil {
+		return nil, s.leaseErr
+	}
+	return s.leaseMgr, nil
+}
+
+// run periodically revokes any lease whose expires_at_ns has passed.
+func (lm *leaseManager[T1, T2]) run() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-lm.stop:
+			return
+		case <-ticker.C:
+			lm.reapExpired()
+		}
+	}
+}
+
+func (lm *leaseManager[T1, T2]) reapExpired() {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := fmt.Sprintf("SELECT lease_id FROM %s WHERE expires_at_ns <= ?", lm.leaseTable)
+	rows, err := lm.store.db.QueryContext(ctx, query, time.Now().UnixNano())
+	if err != nil {
+		return
+	}
+	var expired []LeaseID
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			continue
+		}
+		expired = append(expired, LeaseID(id))
+	}
+	rows.Close()
+
+	for _, id := range expired {
+		lm.revoke(id)
+	}
+}
+
+// Grant creates a new lease with the given TTL and returns its LeaseID.
+func (s *KV[T1, T2]) Grant(ttl time.Duration) (LeaseID, error) {
+	lm, err := s.leases()
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := fmt.Sprintf("INSERT INTO %s (ttl_ns, expires_at_ns) VALUES (?, ?)", lm.leaseTable)
+	result, err := s.db.ExecContext(ctx, query, int64(ttl), time.Now().Add(ttl).UnixNano())
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return LeaseID(id), nil
+}
+
+// SetWithLease stores value at key and attaches it to lease, so it is
+// deleted automatically (along with every other key on the same lease)
+// when the lease expires or is revoked.
+func (s *KV[T1, T2]) SetWithLease(key T1, value T2, lease LeaseID) error {
+	lm, err := s.leases()
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.TrySet(key, value); err != nil {
+		return err
+	}
+
+	keyStr := keyString(key)
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	query := fmt.Sprintf("INSERT OR REPLACE INTO %s (lease_id, key) VALUES (?, ?)", lm.keysTable)
+	_, err = s.db.ExecContext(ctx, query, int64(lease), keyStr)
+	return err
+}
+
+// KeepAlive extends lease's expiry by its original TTL from now.
+func (s *KV[T1, T2]) KeepAlive(lease LeaseID) error {
+	lm, err := s.leases()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var ttlNS int64
+	query := fmt.Sprintf("SELECT ttl_ns FROM %s WHERE lease_id = ?", lm.leaseTable)
+	if err := s.db.QueryRowContext(ctx, query, int64(lease)).Scan(&ttlNS); err != nil {
+		return err
+	}
+
+	update := fmt.Sprintf("UPDATE %s SET expires_at_ns = ? WHERE lease_id = ?", lm.leaseTable)
+	_, err = s.db.ExecContext(ctx, update, time.Now().Add(time.Duration(ttlNS)).UnixNano(), int64(lease))
+	return err
+}
+
+// Revoke deletes every key attached to lease, in a single transaction, and
+// removes the lease itself. Deleted keys publish the usual
+// WatchEventDelete events so existing watchers observe the expiration.
+func (s *KV[T1, T2]) Revoke(lease LeaseID) error {
+	lm, err := s.leases()
+	if err != nil {
+		return err
+	}
+	return lm.revoke(lease)
+}
+
+func (lm *leaseManager[T1, T2]) revoke(lease LeaseID) error {
+	s := lm.store
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	sqlTx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("SELECT key FROM %s WHERE lease_id = ?", lm.keysTable)
+	rows, err := sqlTx.Query(query, int64(lease))
+	if err != nil {
+		sqlTx.Rollback()
+		return err
+	}
+	var keyStrs []string
+	for rows.Next() {
+		var k string
+		if err := rows.Scan(&k); err != nil {
+			rows.Close()
+			sqlTx.Rollback()
+			return err
+		}
+		keyStrs = append(keyStrs, k)
+	}
+	rows.Close()
+
+	// deletedKey pairs each lease-attached key (decoded back to its typed
+	// form) with the value it held just before this revoke, the same way
+	// TryDelete calls getOldValue before its own DELETE, so the watch event
+	// and history entry published below carry a real Key/OldValue instead
+	// of the zero value.
+	type deletedKey struct {
+		key      T1
+		oldValue T2
+		hadValue bool
+	}
+	deleted := make([]deletedKey, 0, len(keyStrs))
+	for _, keyStr := range keyStrs {
+		var k T1
+		if err := json.Unmarshal([]byte(keyStr), &k); err != nil {
+			sqlTx.Rollback()
+			return fmt.Errorf("failed to unmarshal key: %w", err)
+		}
+		oldValue, hadValue := s.getOldValue(k)
+		deleted = append(deleted, deletedKey{key: k, oldValue: oldValue, hadValue: hadValue})
+
+		deleteSQL := fmt.Sprintf("DELETE FROM %s WHERE key = ?", s.table)
+		if _, err := sqlTx.Exec(deleteSQL, keyStr); err != nil {
+			sqlTx.Rollback()
+			return err
+		}
+	}
+
+	deleteKeys := fmt.Sprintf("DELETE FROM %s WHERE lease_id = ?", lm.keysTable)
+	if _, err := sqlTx.Exec(deleteKeys, int64(lease)); err != nil {
+		sqlTx.Rollback()
+		return err
+	}
+	deleteLease := fmt.Sprintf("DELETE FROM %s WHERE lease_id = ?", lm.leaseTable)
+	if _, err := sqlTx.Exec(deleteLease, int64(lease)); err != nil {
+		sqlTx.Rollback()
+		return err
+	}
+
+	if err := sqlTx.Commit(); err != nil {
+		return err
+	}
+
+	// Only now that the transaction is durable do we record history and
+	// notify watchers, mirroring TryDelete; a key whose value was already
+	// gone (e.g. deleted directly, leaving a stale lease_keys row) is
+	// skipped rather than publishing a hollow event for it.
+	for _, d := range deleted {
+		if !d.hadValue {
+			continue
+		}
+		event := WatchEvent[T1, T2]{
+			Type:      WatchEventDelete,
+			Key:       d.key,
+			OldValue:  d.oldValue,
+			Action:    Delete,
+			PrevValue: d.oldValue,
+			Exists:    true,
+		}
+		var zero T2
+		if rev, histErr := s.appendHistory(d.key, d.oldValue, zero, Delete); histErr == nil {
+			event.Rev = rev
+		}
+		if s.broadcaster != nil {
+			s.broadcaster.publish(keyString(d.key), event)
+		}
+	}
+
+	return nil
+}
+
+// stopLeaseReaper stops the background reaper goroutine, if one was ever
+// started. It is unexported and nothing in the package calls it;
+// StopAllWatchers is the only lifecycle hook the store exposes.
+func (s *KV[T1, T2]) stopLeaseReaper() {
+	if s.leaseMgr == nil {
+		return
+	}
+	s.leaseMgr.stopOnce.Do(func() {
+		close(s.leaseMgr.stop)
+	})
+}
@@ -0,0 +1,83 @@
+package kvstore_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/xtdlib/kvstore"
+)
+
+func TestLeaseKeepAliveAndRevoke(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_lease.db")
+
+	store, err := kvstore.NewAt[string, string](dbPath, "test_lease")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	lease, err := store.Grant(5 * time.Second)
+	if err != nil {
+		t.Fatalf("Grant failed: %v", err)
+	}
+
+	if err := store.SetWithLease("session:1", "alice", lease); err != nil {
+		t.Fatalf("SetWithLease failed: %v", err)
+	}
+	if !store.Has("session:1") {
+		t.Fatal("Expected session:1 to exist after SetWithLease")
+	}
+
+	if err := store.KeepAlive(lease); err != nil {
+		t.Fatalf("KeepAlive failed: %v", err)
+	}
+
+	if err := store.Revoke(lease); err != nil {
+		t.Fatalf("Revoke failed: %v", err)
+	}
+	if store.Has("session:1") {
+		t.Fatal("Expected session:1 to be gone after Revoke")
+	}
+}
+
+func TestLeaseExpiresAutomatically(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_lease_expiry.db")
+
+	store, err := kvstore.NewAt[string, string](dbPath, "test_lease_expiry")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	lease, err := store.Grant(200 * time.Millisecond)
+	if err != nil {
+		t.Fatalf("Grant failed: %v", err)
+	}
+	if err := store.SetWithLease("session:2", "bob", lease); err != nil {
+		t.Fatalf("SetWithLease failed: %v", err)
+	}
+
+	eventCh, cancel := store.Watch("session:2")
+	defer cancel()
+
+	select {
+	case event := <-eventCh:
+		if event.Type != kvstore.WatchEventDelete {
+			t.Fatalf("Expected a delete event on expiry, got %+v", event)
+		}
+		if event.Key != "session:2" || event.OldValue != "bob" {
+			t.Fatalf("Expected Key=session:2 and OldValue=bob, got %+v", event)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("Timeout waiting for lease expiry to delete session:2")
+	}
+
+	entries, err := store.History("session:2", 0, 0)
+	if err != nil {
+		t.Fatalf("History failed: %v", err)
+	}
+	if len(entries) == 0 || entries[len(entries)-1].Action != kvstore.Delete {
+		t.Fatalf("Expected the lease expiry's delete to be recorded in history, got %+v", entries)
+	}
+}
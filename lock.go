@@ -0,0 +1,268 @@
+package kvstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/xtdlib/kvstore/backend/sqlite"
+)
+
+// ErrLockNotHeld is returned by Unlock and Refresh when the lock is no
+// longer held by this Lock value (it was never acquired, already
+// unlocked, or its lease was stolen after expiring).
+var ErrLockNotHeld = errors.New("kvstore: lock not held")
+
+// lockRecord is the row kvstore writes for a held lock. It lives in a
+// sibling "<table>_locks" table, independent of the store's own K/V
+// schema, so NewLock works regardless of the store's value type.
+type lockRecord struct {
+	OwnerID        string
+	AcquiredAt     time.Time
+	TTL            time.Duration
+	LeaseExpiresAt time.Time
+}
+
+// LockOpts configures a Lock created by NewLock.
+type LockOpts struct {
+	// TTL is how long a lease is valid without being refreshed before
+	// another waiter may reclaim it. Defaults to 10s.
+	TTL time.Duration
+	// RefreshInterval is how often the background goroutine extends the
+	// lease while held. Defaults to TTL/3.
+	RefreshInterval time.Duration
+	// OwnerID identifies the holder in the lock row. Defaults to
+	// "<hostname>-<pid>".
+	OwnerID string
+}
+
+func (o LockOpts) withDefaults() LockOpts {
+	if o.TTL <= 0 {
+		o.TTL = 10 * time.Second
+	}
+	if o.RefreshInterval <= 0 {
+		o.RefreshInterval = o.TTL / 3
+	}
+	if o.OwnerID == "" {
+		host, _ := os.Hostname()
+		o.OwnerID = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+	return o
+}
+
+// Lock is a distributed, TTL-based mutex backed by the store. Waiters
+// block on changes to the lock's key instead of polling.
+type Lock[T1 comparable] struct {
+	lockKV *KV[string, lockRecord]
+	key    string
+	opts   LockOpts
+
+	mu          sync.Mutex
+	held        bool
+	stopRefresh chan struct{}
+	refreshWG   sync.WaitGroup
+}
+
+// NewLock returns a Lock for key. Nothing is acquired until Lock or
+// TryLock is called.
+func (s *KV[T1, T2]) NewLock(key T1, opts LockOpts) *Lock[T1] {
+	return &Lock[T1]{
+		lockKV: s.lockStore(),
+		key:    keyString(key),
+		opts:   opts.withDefaults(),
+	}
+}
+
+// lockStore lazily creates the sibling table used to store lock rows, on
+// the same database connection as the parent store, so a store that never
+// calls NewLock never pays for it.
+func (s *KV[T1, T2]) lockStore() *KV[string, lockRecord] {
+	s.lockOnce.Do(func() {
+		lkv := &KV[string, lockRecord]{
+			db:                  s.db,
+			table:               s.table + "_locks",
+			coreBackend:         sqlite.New(s.db),
+			usingDefaultBackend: true,
+		}
+		lkv.broadcaster = newBroadcaster[string, lockRecord]()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+
+		createSQL := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (key PRIMARY KEY, value, expiry INTEGER)", lkv.table)
+		if _, err := lkv.db.ExecContext(ctx, createSQL); err != nil {
+			s.lockErr = err
+			return
+		}
+		if err := lkv.initHistory(ctx); err != nil {
+			s.lockErr = err
+			return
+		}
+		s.lockKV = lkv
+	})
+	if s.lockErr != nil {
+		panic(s.lockErr)
+	}
+	return s.lockKV
+}
+
+// TryLock attempts to acquire the lock without blocking. It succeeds
+// immediately if the key is absent, or if the existing holder's lease has
+// expired, in which case it atomically steals the lock.
+func (l *Lock[T1]) TryLock() (bool, error) {
+	record := lockRecord{
+		OwnerID:        l.opts.OwnerID,
+		AcquiredAt:     time.Now(),
+		TTL:            l.opts.TTL,
+		LeaseExpiresAt: time.Now().Add(l.opts.TTL),
+	}
+
+	created, err := l.lockKV.CreateOnly(l.key, record)
+	if err == nil && created {
+		l.onAcquired()
+		return true, nil
+	}
+	if err != nil && !errors.Is(err, ErrAlreadyExists) {
+		return false, err
+	}
+
+	// Key exists; reclaim it only if its lease has expired.
+	existing, err := l.lockKV.TryGet(l.key)
+	if err != nil {
+		return false, err
+	}
+	if time.Now().Before(existing.LeaseExpiresAt) {
+		return false, nil
+	}
+	swapped, err := l.lockKV.CompareAndSwap(l.key, existing, record)
+	if err != nil {
+		return false, err
+	}
+	if swapped {
+		l.onAcquired()
+	}
+	return swapped, nil
+}
+
+// Lock blocks until the lock is acquired or ctx is canceled. Instead of
+// polling, it subscribes to the broadcaster for this key and retries
+// TryLock whenever the holder releases or its lease would have expired.
+func (l *Lock[T1]) Lock(ctx context.Context) error {
+	for {
+		acquired, err := l.TryLock()
+		if err != nil {
+			return err
+		}
+		if acquired {
+			return nil
+		}
+
+		existing, err := l.lockKV.TryGet(l.key)
+		var wait time.Duration
+		if err == nil {
+			wait = time.Until(existing.LeaseExpiresAt)
+		}
+		if wait <= 0 {
+			wait = l.opts.TTL
+		}
+
+		eventCh, cancel := l.lockKV.Watch(l.key)
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			cancel()
+			return ctx.Err()
+		case <-eventCh:
+			// Holder changed (deleted, stolen, or re-acquired); retry.
+		case <-timer.C:
+			// Lease should have expired by now; retry and reclaim it.
+		}
+		timer.Stop()
+		cancel()
+	}
+}
+
+// Unlock releases the lock, but only if it is still held by this Lock
+// value; it returns ErrLockNotHeld otherwise (e.g. the lease already
+// expired and was stolen by another waiter).
+func (l *Lock[T1]) Unlock() error {
+	l.mu.Lock()
+	if !l.held {
+		l.mu.Unlock()
+		return ErrLockNotHeld
+	}
+	l.held = false
+	stopRefresh := l.stopRefresh
+	l.stopRefresh = nil
+	l.mu.Unlock()
+
+	if stopRefresh != nil {
+		close(stopRefresh)
+		l.refreshWG.Wait()
+	}
+
+	existing, err := l.lockKV.TryGet(l.key)
+	if err != nil {
+		return nil // already gone
+	}
+	if existing.OwnerID != l.opts.OwnerID {
+		return ErrLockNotHeld
+	}
+	if err := l.lockKV.TryDelete(l.key); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Refresh extends the lease by TTL from now, failing with ErrLockNotHeld
+// if this Lock value is no longer the owner of record.
+func (l *Lock[T1]) Refresh() error {
+	existing, err := l.lockKV.TryGet(l.key)
+	if err != nil {
+		return ErrLockNotHeld
+	}
+	if existing.OwnerID != l.opts.OwnerID {
+		return ErrLockNotHeld
+	}
+
+	refreshed := existing
+	refreshed.LeaseExpiresAt = time.Now().Add(l.opts.TTL)
+	swapped, err := l.lockKV.CompareAndSwap(l.key, existing, refreshed)
+	if err != nil {
+		return err
+	}
+	if !swapped {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+// onAcquired marks the lock as held and starts the background refresh
+// loop that keeps the lease alive until Unlock.
+func (l *Lock[T1]) onAcquired() {
+	l.mu.Lock()
+	l.held = true
+	stop := make(chan struct{})
+	l.stopRefresh = stop
+	l.mu.Unlock()
+
+	l.refreshWG.Add(1)
+	go func() {
+		defer l.refreshWG.Done()
+		ticker := time.NewTicker(l.opts.RefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				_ = l.Refresh()
+			}
+		}
+	}()
+}
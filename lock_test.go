@@ -0,0 +1,85 @@
+package kvstore_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/xtdlib/kvstore"
+)
+
+func TestLockTryLockAndUnlock(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_lock.db")
+
+	store, err := kvstore.NewAt[string, string](dbPath, "test_lock")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	lockA := store.NewLock("resource", kvstore.LockOpts{TTL: 200 * time.Millisecond, OwnerID: "a"})
+	lockB := store.NewLock("resource", kvstore.LockOpts{TTL: 200 * time.Millisecond, OwnerID: "b"})
+
+	acquired, err := lockA.TryLock()
+	if err != nil || !acquired {
+		t.Fatalf("Expected lockA to acquire, got %v, %v", acquired, err)
+	}
+
+	acquired, err = lockB.TryLock()
+	if err != nil {
+		t.Fatalf("TryLock failed: %v", err)
+	}
+	if acquired {
+		t.Fatal("Expected lockB to fail while lockA holds the lease")
+	}
+
+	if err := lockA.Unlock(); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+
+	acquired, err = lockB.TryLock()
+	if err != nil || !acquired {
+		t.Fatalf("Expected lockB to acquire after unlock, got %v, %v", acquired, err)
+	}
+	lockB.Unlock()
+}
+
+func TestLockBlocksUntilReleased(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_lock_block.db")
+
+	store, err := kvstore.NewAt[string, string](dbPath, "test_lock_block")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	lockA := store.NewLock("resource", kvstore.LockOpts{TTL: 5 * time.Second, OwnerID: "a"})
+	lockB := store.NewLock("resource", kvstore.LockOpts{TTL: 5 * time.Second, OwnerID: "b"})
+
+	if acquired, err := lockA.TryLock(); err != nil || !acquired {
+		t.Fatalf("Expected lockA to acquire, got %v, %v", acquired, err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		done <- lockB.Lock(ctx)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	if err := lockA.Unlock(); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Expected lockB to acquire once released, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timeout waiting for lockB to acquire")
+	}
+	lockB.Unlock()
+}
@@ -0,0 +1,100 @@
+package kvstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"sync/atomic"
+	"time"
+)
+
+// GetRev returns the value key held at revision rev (its most recent
+// write with ModRev <= rev), along with that write's revision. It reads
+// the history table built by TrySet/TryDelete rather than a separate
+// tombstoned primary table, so it shares Compact's retention window: a
+// rev below the compaction floor returns ErrCompacted. A rev for which
+// the key's last write by then was a delete, was discarded by Compact, or
+// never existed returns sql.ErrNoRows, matching TryGet's behavior for an absent key.
+func (s *KV[T1, T2]) GetRev(key T1, rev int64) (T2, int64, error) {
+	var zero T2
+
+	// Compare against the compaction floor directly rather than always
+	// calling History from fromRev=0: History itself returns ErrCompacted
+	// whenever fromRev is below the floor, which would make every GetRev
+	// call fail after any Compact, even for a rev that's still retrievable.
+	compactedRev := atomic.LoadInt64(&s.compactedRev)
+	if rev < compactedRev {
+		return zero, 0, ErrCompacted
+	}
+
+	entries, err := s.History(key, compactedRev, 0)
+	if err != nil {
+		return zero, 0, err
+	}
+
+	var latest *HistoryEntry[T1, T2]
+	for i := range entries {
+		if entries[i].Rev > rev {
+			break
+		}
+		latest = &entries[i]
+	}
+	if latest == nil || latest.Action == Delete {
+		return zero, 0, sql.ErrNoRows
+	}
+	return latest.NewValue, latest.Rev, nil
+}
+
+// IterAtRev returns an iterator, for use with Go 1.23+ range-over-func,
+// over every key as it stood at revision rev: each key's most recent
+// write with ModRev <= rev, excluding keys whose most recent such write
+// was a delete. Like GetRev, this is a time-travel read over the history
+// table rather than a tombstoned primary table.
+func (s *KV[T1, T2]) IterAtRev(rev int64) iter.Seq2[T1, T2] {
+	return func(yield func(T1, T2) bool) {
+		query := fmt.Sprintf(`
+			SELECT h.key, h.new_value, h.action
+			FROM %s h
+			INNER JOIN (
+				SELECT key, MAX(rev) AS rev
+				FROM %s
+				WHERE rev <= ?
+				GROUP BY key
+			) latest ON h.key = latest.key AND h.rev = latest.rev
+			ORDER BY h.key
+		`, s.historyTable(), s.historyTable())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		rows, err := s.db.QueryContext(ctx, query, rev)
+		if err != nil {
+			return
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var keyStr, valueStr string
+			var action int
+			if err := rows.Scan(&keyStr, &valueStr, &action); err != nil {
+				return
+			}
+			if Action(action) == Delete {
+				continue
+			}
+
+			var k T1
+			var v T2
+			if err := json.Unmarshal([]byte(keyStr), &k); err != nil {
+				return
+			}
+			if err := json.Unmarshal([]byte(valueStr), &v); err != nil {
+				return
+			}
+			if !yield(k, v) {
+				return
+			}
+		}
+	}
+}
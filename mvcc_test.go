@@ -0,0 +1,91 @@
+package kvstore_test
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/xtdlib/kvstore"
+)
+
+func TestGetRevAndIterAtRev(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_mvcc.db")
+
+	store, err := kvstore.NewAt[string, string](dbPath, "test_mvcc")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	store.Set("key1", "v1")
+	entries, _ := store.History("key1", 0, 0)
+	rev1 := entries[0].Rev
+
+	store.Set("key1", "v2")
+	store.Set("key2", "other")
+
+	val, modRev, err := store.GetRev("key1", rev1)
+	if err != nil {
+		t.Fatalf("GetRev failed: %v", err)
+	}
+	if val != "v1" || modRev != rev1 {
+		t.Fatalf("Expected v1 at rev %d, got %s at rev %d", rev1, val, modRev)
+	}
+
+	val, _, err = store.GetRev("key1", rev1+100)
+	if err != nil || val != "v2" {
+		t.Fatalf("Expected v2 at later rev, got %s, err=%v", val, err)
+	}
+
+	seen := make(map[string]string)
+	for k, v := range store.IterAtRev(rev1) {
+		seen[k] = v
+	}
+	if len(seen) != 1 || seen["key1"] != "v1" {
+		t.Fatalf("Expected only key1=v1 at rev1, got %v", seen)
+	}
+
+	store.Delete("key1")
+	if _, _, err := store.GetRev("key1", rev1+1000); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("Expected sql.ErrNoRows after delete, got %v", err)
+	}
+}
+
+func TestGetRevAfterCompact(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_mvcc_compact.db")
+
+	store, err := kvstore.NewAt[string, string](dbPath, "test_mvcc_compact")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	store.Set("key1", "v1")
+	entries, _ := store.History("key1", 0, 0)
+	rev1 := entries[0].Rev
+
+	store.Set("key1", "v2")
+	entries, _ = store.History("key1", 0, 0)
+	rev2 := entries[len(entries)-1].Rev
+
+	store.Set("key1", "v3")
+
+	if err := store.Compact(rev1); err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+
+	// rev2 is still retrievable: it's above the compaction floor (rev1).
+	val, modRev, err := store.GetRev("key1", rev2)
+	if err != nil {
+		t.Fatalf("Expected rev2 to survive Compact(rev1), got err=%v", err)
+	}
+	if val != "v2" || modRev != rev2 {
+		t.Fatalf("Expected v2 at rev %d, got %s at rev %d", rev2, val, modRev)
+	}
+
+	// A rev below the compaction floor is refused outright.
+	if _, _, err := store.GetRev("key1", rev1-1); !errors.Is(err, kvstore.ErrCompacted) {
+		t.Fatalf("Expected ErrCompacted for a rev below the compaction floor, got %v", err)
+	}
+}
@@ -0,0 +1,27 @@
+package kvstore
+
+import "github.com/xtdlib/kvstore/backend"
+
+// storeConfig accumulates every constructor Option before New/NewAt
+// builds the store, so options for unrelated features (TTL reaper
+// tuning, backend selection, ...) can share one Option type without one
+// feature's fields leaking into call sites that don't use it.
+type storeConfig struct {
+	ttl     ttlConfig
+	backend backend.Backend
+}
+
+// Option configures a store created by New/NewAt. This is the first
+// constructor-level option in the package (WatchOption configures an
+// individual subscription, not the store itself).
+type Option func(*storeConfig)
+
+// WithBackend overrides the backend.Backend used for the store's primary
+// key/value table, in place of the default SQLite-backed one (see
+// backend/sqlite). Revision history, secondary indexes, leases, atomic
+// compare-and-swap, transactions, and range scans still talk to SQLite
+// directly and require the default backend; AddIndex returns an error on
+// a store constructed with a non-default one.
+func WithBackend(b backend.Backend) Option {
+	return func(c *storeConfig) { c.backend = b }
+}
@@ -0,0 +1,186 @@
+package kvstore_test
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xtdlib/kvstore"
+	"github.com/xtdlib/kvstore/backend"
+)
+
+// memBackend is a minimal in-memory backend.Backend, used to prove KV
+// actually reads and writes through whatever Backend WithBackend supplies
+// rather than always falling back to SQLite.
+type memBackend struct {
+	mu     sync.Mutex
+	tables map[string]map[string]backend.Row
+}
+
+func newMemBackend() *memBackend {
+	return &memBackend{tables: make(map[string]map[string]backend.Row)}
+}
+
+func (b *memBackend) EnsureTable(ctx context.Context, table string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tables[table] == nil {
+		b.tables[table] = make(map[string]backend.Row)
+	}
+	return nil
+}
+
+func (b *memBackend) Get(ctx context.Context, table, key string) (backend.Row, bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	row, ok := b.tables[table][key]
+	return row, ok, nil
+}
+
+func (b *memBackend) Put(ctx context.Context, table, key string, row backend.Row) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tables[table][key] = row
+	return nil
+}
+
+func (b *memBackend) Delete(ctx context.Context, table, key string) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	_, existed := b.tables[table][key]
+	delete(b.tables[table], key)
+	return existed, nil
+}
+
+func (b *memBackend) Clear(ctx context.Context, table string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tables[table] = make(map[string]backend.Row)
+	return nil
+}
+
+func (b *memBackend) Close() error { return nil }
+
+func TestWithBackendRoutesCoreOpsThroughIt(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_with_backend.db")
+
+	mem := newMemBackend()
+	store, err := kvstore.NewAt[string, int](dbPath, "test_with_backend", kvstore.WithBackend(mem))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	store.Set("a", 1)
+	if store.Get("a") != 1 {
+		t.Fatalf("Expected Get to read back the value written through WithBackend's backend")
+	}
+	if len(mem.tables["test_with_backend"]) != 1 {
+		t.Fatalf("Expected the custom backend to actually hold the row, got %+v", mem.tables)
+	}
+
+	if !store.Has("a") {
+		t.Fatal("Expected Has to report true")
+	}
+	store.Delete("a")
+	if store.Has("a") {
+		t.Fatal("Expected Has to report false after Delete")
+	}
+}
+
+func TestWithBackendRefusesAddIndex(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_with_backend_index.db")
+
+	store, err := kvstore.NewAt[string, int](dbPath, "test_with_backend_index", kvstore.WithBackend(newMemBackend()))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	if err := store.AddIndex("idx", func(_ string, v int) []byte { return nil }); err == nil {
+		t.Fatal("Expected AddIndex to refuse a non-default Backend")
+	}
+}
+
+// TestWithBackendRefusesRawSQLFeatures covers every feature that still
+// reads/writes the primary (or a sibling) table with raw SQL rather than
+// through coreBackend: each must refuse outright on a non-default Backend
+// rather than silently operating on an empty SQLite table, the same way
+// AddIndex already does.
+func TestWithBackendRefusesRawSQLFeatures(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_with_backend_rawsql.db")
+
+	store, err := kvstore.NewAt[string, int](dbPath, "test_with_backend_rawsql", kvstore.WithBackend(newMemBackend()))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	store.Set("a", 1)
+
+	if err := store.TryForEach(func(string, int) {}); err == nil {
+		t.Error("Expected TryForEach to refuse a non-default Backend")
+	}
+	if _, err := store.History("a", 0, 0); err == nil {
+		t.Error("Expected History to refuse a non-default Backend")
+	}
+	if err := store.Compact(0); err == nil {
+		t.Error("Expected Compact to refuse a non-default Backend")
+	}
+	if _, err := store.CompareAndSwap("a", 1, 2); err == nil {
+		t.Error("Expected CompareAndSwap to refuse a non-default Backend")
+	}
+	if _, err := store.CreateOnly("b", 2); err == nil {
+		t.Error("Expected CreateOnly to refuse a non-default Backend")
+	}
+	if err := store.Transaction(func(tx *kvstore.Tx[string, int]) error { return nil }); err == nil {
+		t.Error("Expected Transaction to refuse a non-default Backend")
+	}
+	if _, err := store.CountPrefix("a"); err == nil {
+		t.Error("Expected CountPrefix to refuse a non-default Backend")
+	}
+	if _, err := store.Grant(time.Second); err == nil {
+		t.Error("Expected Grant to refuse a non-default Backend")
+	}
+
+	seen := false
+	for range store.Iter() {
+		seen = true
+	}
+	if seen {
+		t.Error("Expected Iter to yield nothing on a non-default Backend")
+	}
+}
+
+// TestWithBackendGetOldValueReportsRealPriorValue proves getOldValue (used
+// internally by TryDelete/TrySet to classify Create vs. Update and to
+// populate watch events) reads through the custom Backend instead of
+// always reporting "no prior value", as it did before it was rewired off
+// raw SQL against s.db.
+func TestWithBackendGetOldValueReportsRealPriorValue(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_with_backend_old_value.db")
+
+	store, err := kvstore.NewAt[string, string](dbPath, "test_with_backend_old_value", kvstore.WithBackend(newMemBackend()))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	eventCh, cancel := store.Watch("k")
+	defer cancel()
+
+	store.Set("k", "v1")
+	<-eventCh // drain the Create event
+
+	store.Set("k", "v2")
+	select {
+	case event := <-eventCh:
+		if event.Action != kvstore.Update || event.OldValue != "v1" {
+			t.Fatalf("Expected an Update event carrying OldValue v1, got %+v", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for Update event")
+	}
+}
@@ -0,0 +1,194 @@
+package kvstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"strings"
+)
+
+// likePattern turns the JSON encoding of prefix into a SQL LIKE pattern
+// matching every key that starts with it: the closing quote JSON adds for
+// string-ish values is stripped, and LIKE's own metacharacters are escaped.
+func likePattern[T1 comparable](prefix T1) (string, error) {
+	prefixBytes, err := json.Marshal(prefix)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal prefix: %w", err)
+	}
+	s := string(prefixBytes)
+	s = strings.TrimSuffix(s, `"`)
+	escaper := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return escaper.Replace(s) + "%", nil
+}
+
+// DeleteRange deletes every key k with lo <= k < hi in a single SQL
+// statement within a transaction, and returns how many keys were removed.
+func (tx *Tx[T1, T2]) DeleteRange(lo, hi T1) (int, error) {
+	loBytes, err := json.Marshal(lo)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal lo: %w", err)
+	}
+	hiBytes, err := json.Marshal(hi)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal hi: %w", err)
+	}
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE key >= ? AND key < ? RETURNING key, value", tx.table)
+	return tx.deleteReturning(query, string(loBytes), string(hiBytes))
+}
+
+// DeletePrefix deletes every key with the given prefix in a single SQL
+// statement within a transaction, and returns how many keys were removed.
+func (tx *Tx[T1, T2]) DeletePrefix(prefix T1) (int, error) {
+	pattern, err := likePattern(prefix)
+	if err != nil {
+		return 0, err
+	}
+	query := fmt.Sprintf("DELETE FROM %s WHERE key LIKE ? ESCAPE '\\' RETURNING key, value", tx.table)
+	return tx.deleteReturning(query, pattern)
+}
+
+func (tx *Tx[T1, T2]) deleteReturning(query string, args ...any) (int, error) {
+	rows, err := tx.tx.Query(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		var keyStr, valueStr string
+		if err := rows.Scan(&keyStr, &valueStr); err != nil {
+			return count, err
+		}
+		var k T1
+		var v T2
+		if err := json.Unmarshal([]byte(keyStr), &k); err != nil {
+			return count, fmt.Errorf("failed to unmarshal key: %w", err)
+		}
+		if err := json.Unmarshal([]byte(valueStr), &v); err != nil {
+			return count, fmt.Errorf("failed to unmarshal value: %w", err)
+		}
+		if err := tx.syncIndexDelete([]byte(keyStr)); err != nil {
+			return count, err
+		}
+		tx.pendingEvents = append(tx.pendingEvents, WatchEvent[T1, T2]{
+			Type:      WatchEventDelete,
+			Key:       k,
+			OldValue:  v,
+			Action:    Delete,
+			PrevValue: v,
+			Exists:    true,
+		})
+		count++
+	}
+	return count, rows.Err()
+}
+
+// DeleteRange deletes every key k with lo <= k < hi as a single SQL
+// statement, and emits one watch event per deleted key.
+func (s *KV[T1, T2]) DeleteRange(lo, hi T1) (int, error) {
+	var count int
+	err := s.Transaction(func(tx *Tx[T1, T2]) error {
+		n, err := tx.DeleteRange(lo, hi)
+		count = n
+		return err
+	})
+	return count, err
+}
+
+// DeletePrefix deletes every key with the given prefix as a single SQL
+// statement, and emits one watch event per deleted key. This is the
+// recursive-delete operation common to Consul-style KV APIs, expressed
+// without an N+1 loop over Keys + Delete.
+func (s *KV[T1, T2]) DeletePrefix(prefix T1) (int, error) {
+	var count int
+	err := s.Transaction(func(tx *Tx[T1, T2]) error {
+		n, err := tx.DeletePrefix(prefix)
+		count = n
+		return err
+	})
+	return count, err
+}
+
+// CountPrefix returns the number of keys with the given prefix.
+func (s *KV[T1, T2]) CountPrefix(prefix T1) (int, error) {
+	if err := s.requireDefaultBackend("CountPrefix"); err != nil {
+		return 0, err
+	}
+
+	pattern, err := likePattern(prefix)
+	if err != nil {
+		return 0, err
+	}
+
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE key LIKE ? ESCAPE '\\'", s.table)
+	var count int
+	if err := s.db.QueryRowContext(context.Background(), query, pattern).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// Range returns an iterator, for use with Go 1.23+ range-over-func, over
+// every key k with lo <= k < hi in key order.
+func (s *KV[T1, T2]) Range(lo, hi T1) iter.Seq2[T1, T2] {
+	return func(yield func(T1, T2) bool) {
+		loBytes, err := json.Marshal(lo)
+		if err != nil {
+			return
+		}
+		hiBytes, err := json.Marshal(hi)
+		if err != nil {
+			return
+		}
+
+		query := fmt.Sprintf("SELECT key, value FROM %s WHERE key >= ? AND key < ? ORDER BY key", s.table)
+		s.iterateRows("Range", query, []any{string(loBytes), string(hiBytes)}, yield)
+	}
+}
+
+// RangePrefix returns an iterator, for use with Go 1.23+ range-over-func,
+// over every key with the given prefix in key order.
+func (s *KV[T1, T2]) RangePrefix(prefix T1) iter.Seq2[T1, T2] {
+	return func(yield func(T1, T2) bool) {
+		pattern, err := likePattern(prefix)
+		if err != nil {
+			return
+		}
+
+		query := fmt.Sprintf("SELECT key, value FROM %s WHERE key LIKE ? ESCAPE '\\' ORDER BY key", s.table)
+		s.iterateRows("RangePrefix", query, []any{pattern}, yield)
+	}
+}
+
+func (s *KV[T1, T2]) iterateRows(op string, query string, args []any, yield func(T1, T2) bool) {
+	if s.requireDefaultBackend(op) != nil {
+		return
+	}
+
+	rows, err := s.db.QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var k T1
+		var v T2
+		var keyStr, valueStr string
+		if err := rows.Scan(&keyStr, &valueStr); err != nil {
+			return
+		}
+		if err := json.Unmarshal([]byte(keyStr), &k); err != nil {
+			return
+		}
+		if err := json.Unmarshal([]byte(valueStr), &v); err != nil {
+			return
+		}
+		if !yield(k, v) {
+			return
+		}
+	}
+}
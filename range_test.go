@@ -0,0 +1,125 @@
+package kvstore_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/xtdlib/kvstore"
+)
+
+func TestRange(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_range.db")
+
+	store, err := kvstore.NewAt[string, int](dbPath, "test_range")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	for k, v := range map[string]int{"a": 1, "b": 2, "c": 3, "d": 4} {
+		store.Set(k, v)
+	}
+
+	var keys []string
+	for k := range store.Range("b", "d") {
+		keys = append(keys, k)
+	}
+	if len(keys) != 2 || keys[0] != "b" || keys[1] != "c" {
+		t.Fatalf("Expected [b c], got %v", keys)
+	}
+}
+
+func TestRangePrefix(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_range_prefix.db")
+
+	store, err := kvstore.NewAt[string, string](dbPath, "test_range_prefix")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	store.Set("user:1", "Alice")
+	store.Set("user:2", "Bob")
+	store.Set("admin:1", "Root")
+
+	count := 0
+	for range store.RangePrefix("user:") {
+		count++
+	}
+	if count != 2 {
+		t.Fatalf("Expected 2 keys with user: prefix, got %d", count)
+	}
+
+	n, err := store.CountPrefix("user:")
+	if err != nil {
+		t.Fatalf("CountPrefix failed: %v", err)
+	}
+	if n != 2 {
+		t.Fatalf("Expected CountPrefix=2, got %d", n)
+	}
+}
+
+func TestDeletePrefixAndRange(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_delete_prefix.db")
+
+	store, err := kvstore.NewAt[string, string](dbPath, "test_delete_prefix")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	eventCh, cancel := store.WatchPrefix(nil, "user:")
+	defer cancel()
+
+	store.Set("user:1", "Alice")
+	store.Set("user:2", "Bob")
+	store.Set("admin:1", "Root")
+
+	// The watcher also saw the two user: Creates; consume them so the loop
+	// below only reads what DeletePrefix emits.
+	for i := 0; i < 2; i++ {
+		select {
+		case event := <-eventCh:
+			if event.Action != kvstore.Create {
+				t.Errorf("Expected Create action, got %v", event.Action)
+			}
+		case <-time.After(1 * time.Second):
+			t.Fatal("Timeout waiting for create event")
+		}
+	}
+
+	n, err := store.DeletePrefix("user:")
+	if err != nil {
+		t.Fatalf("DeletePrefix failed: %v", err)
+	}
+	if n != 2 {
+		t.Fatalf("Expected to delete 2 keys, got %d", n)
+	}
+	if store.Has("admin:1") != true {
+		t.Fatal("Expected admin:1 to survive DeletePrefix")
+	}
+
+	seen := 0
+	for i := 0; i < 2; i++ {
+		select {
+		case event := <-eventCh:
+			if event.Action != kvstore.Delete {
+				t.Errorf("Expected Delete action, got %v", event.Action)
+			}
+			seen++
+		case <-time.After(1 * time.Second):
+			t.Fatal("Timeout waiting for delete event")
+		}
+	}
+	if seen != 2 {
+		t.Fatalf("Expected 2 delete events, got %d", seen)
+	}
+
+	if _, err := store.DeleteRange("a", "z"); err != nil {
+		t.Fatalf("DeleteRange failed: %v", err)
+	}
+	if store.Has("admin:1") {
+		t.Fatal("Expected admin:1 removed by DeleteRange")
+	}
+}
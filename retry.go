@@ -0,0 +1,50 @@
+package kvstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+const maxBeginAttempts = 5
+
+// beginImmediate starts a transaction with SQLite's equivalent of BEGIN
+// IMMEDIATE (acquiring the write lock up front, rather than on the first
+// write) so two concurrent transactions fail fast at BeginTx instead of
+// deadlocking or racing each other partway through. Since SQLite only
+// allows one writer at a time, acquiring that lock can return
+// SQLITE_BUSY under contention even with busy_timeout set; this retries
+// with exponential backoff rather than surfacing that transiently to
+// every caller of Transaction/Txn.
+func beginImmediate(ctx context.Context, db *sql.DB) (*sql.Tx, error) {
+	opts := &sql.TxOptions{Isolation: sql.LevelSerializable}
+
+	backoff := 10 * time.Millisecond
+	for attempt := 0; ; attempt++ {
+		tx, err := db.BeginTx(ctx, opts)
+		if err == nil {
+			return tx, nil
+		}
+		if !isBusyErr(err) || attempt == maxBeginAttempts-1 {
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func isBusyErr(err error) bool {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code == sqlite3.ErrBusy
+	}
+	return false
+}
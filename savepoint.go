@@ -0,0 +1,62 @@
+package kvstore
+
+import "fmt"
+
+// Savepoint marks a point inside tx that a later RollbackTo can undo back
+// to, without aborting the whole outer transaction. Savepoints may be
+// nested: rolling back an inner one leaves an outer one (and anything
+// before it) untouched.
+func (tx *Tx[T1, T2]) Savepoint(name string) error {
+	if _, err := tx.tx.Exec(fmt.Sprintf("SAVEPOINT %s", name)); err != nil {
+		return err
+	}
+	if tx.savepointMarks == nil {
+		tx.savepointMarks = make(map[string]int)
+	}
+	tx.savepointMarks[name] = len(tx.pendingEvents)
+	return nil
+}
+
+// RollbackTo undoes every Set/Delete made since the matching Savepoint
+// call, along with the watch events and history entries they would have
+// recorded at commit, leaving the savepoint itself open so tx can keep making changes
+// (and later commit, or roll back further).
+func (tx *Tx[T1, T2]) RollbackTo(name string) error {
+	if _, err := tx.tx.Exec(fmt.Sprintf("ROLLBACK TO SAVEPOINT %s", name)); err != nil {
+		return err
+	}
+	if mark, ok := tx.savepointMarks[name]; ok && mark <= len(tx.pendingEvents) {
+		tx.pendingEvents = tx.pendingEvents[:mark]
+	}
+	return nil
+}
+
+// Release discards a savepoint without undoing its changes; they become
+// part of the enclosing transaction (or savepoint).
+func (tx *Tx[T1, T2]) Release(name string) error {
+	_, err := tx.tx.Exec(fmt.Sprintf("RELEASE SAVEPOINT %s", name))
+	return err
+}
+
+// Nested runs fn under a fresh, uniquely-named savepoint: if fn returns an
+// error, only fn's changes are rolled back (via RollbackTo) and the error
+// is returned; tx itself is left usable for more work. If fn succeeds, the
+// savepoint is released. This is the ergonomic counterpart to
+// Savepoint/RollbackTo/Release for the common try-a-sub-sequence pattern.
+func (tx *Tx[T1, T2]) Nested(fn func(sub *Tx[T1, T2]) error) error {
+	tx.savepointSeq++
+	name := fmt.Sprintf("kvstore_sp_%d", tx.savepointSeq)
+
+	if err := tx.Savepoint(name); err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		if rbErr := tx.RollbackTo(name); rbErr != nil {
+			return fmt.Errorf("rollback to savepoint failed: %v (original error: %w)", rbErr, err)
+		}
+		return err
+	}
+
+	return tx.Release(name)
+}
@@ -0,0 +1,68 @@
+package kvstore_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/xtdlib/kvstore"
+)
+
+func TestNestedRollsBackOnlyInnerChanges(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_savepoint.db")
+
+	store, err := kvstore.NewAt[string, string](dbPath, "test_savepoint")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	errBoom := errors.New("boom")
+	err = store.Transaction(func(tx *kvstore.Tx[string, string]) error {
+		tx.Set("outer", "kept")
+
+		nestedErr := tx.Nested(func(sub *kvstore.Tx[string, string]) error {
+			sub.Set("inner", "should not survive")
+			return errBoom
+		})
+		if !errors.Is(nestedErr, errBoom) {
+			t.Fatalf("Expected Nested to surface errBoom, got %v", nestedErr)
+		}
+
+		tx.Set("after", "also kept")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Outer transaction failed: %v", err)
+	}
+
+	if !store.Has("outer") || !store.Has("after") {
+		t.Fatal("Expected outer-scope writes to survive the inner rollback")
+	}
+	if store.Has("inner") {
+		t.Fatal("Expected inner write to have been rolled back")
+	}
+}
+
+func TestNestedCommitsOnSuccess(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_savepoint_ok.db")
+
+	store, err := kvstore.NewAt[string, string](dbPath, "test_savepoint_ok")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	err = store.Transaction(func(tx *kvstore.Tx[string, string]) error {
+		return tx.Nested(func(sub *kvstore.Tx[string, string]) error {
+			sub.Set("inner", "survives")
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatalf("Transaction failed: %v", err)
+	}
+	if !store.Has("inner") {
+		t.Fatal("Expected inner write to survive a successful Nested call")
+	}
+}
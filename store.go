@@ -3,16 +3,17 @@ package kvstore
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 	"sync"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3" // SQLite driver
+
+	"github.com/xtdlib/kvstore/backend"
+	"github.com/xtdlib/kvstore/backend/sqlite"
 )
 
 // modernc.org/sqlite constanly returns "database is locked" errors under high concurrency
@@ -24,9 +25,72 @@ var (
 )
 
 type KV[T1 comparable, T2 comparable] struct {
-	db       *sql.DB
-	table    string
-	watchers *watcherRegistry[T1, T2]
+	db          *sql.DB
+	table       string
+	broadcaster *broadcaster[T1, T2]
+
+	// coreBackend is what TrySet/TryGet/TryHas/TryDelete/TryClear
+	// actually read and write. It defaults to a sqlite.Backend wrapping
+	// db; WithBackend overrides it. usingDefaultBackend is false in the
+	// latter case, which requireDefaultBackend checks: secondary indexes,
+	// revision history, leases, atomic compare-and-swap, transactions,
+	// iteration, and range scans all read/write the primary table with raw
+	// SQL against db directly, so they refuse to run on a non-default
+	// Backend, whose rows are not in that table.
+	coreBackend         backend.Backend
+	usingDefaultBackend bool
+
+	retention    HistoryRetention
+	compactedRev int64 // accessed via sync/atomic
+
+	lockOnce sync.Once
+	lockKV   *KV[string, lockRecord]
+	lockErr  error
+
+	leaseOnce sync.Once
+	leaseMgr  *leaseManager[T1, T2]
+	leaseErr  error
+
+	ttlCfg     ttlConfig
+	reaperStop chan struct{}
+
+	indexMu sync.RWMutex
+	indexes map[string]*indexInfo[T1, T2]
+
+	// keyCodec and valueCodec are nil for stores created by New/NewAt,
+	// which keeps their original encoding/json behavior. NewWithCodec/
+	// NewAtWithCodec set them explicitly; kc()/vc()
+	// fall back to JSONCodec{} when nil.
+	keyCodec   KeyCodec
+	valueCodec Codec
+}
+
+// requireDefaultBackend returns an error if the store was constructed with
+// WithBackend overriding the default SQLite backend. op names the feature
+// for the error message: revision history, secondary indexes, leases,
+// atomic compare-and-swap, transactions, and range scans all read/write the
+// primary (or a sibling) table with raw SQL that a WithBackend override's
+// rows don't populate, so they refuse to run at all rather than silently
+// operating on an empty table.
+func (s *KV[T1, T2]) requireDefaultBackend(op string) error {
+	if !s.usingDefaultBackend {
+		return fmt.Errorf("kvstore: %s requires the default SQLite backend on table %s; it reads/writes with raw SQL that a WithBackend override's rows don't populate", op, s.table)
+	}
+	return nil
+}
+
+func (s *KV[T1, T2]) kc() KeyCodec {
+	if s.keyCodec == nil {
+		return JSONCodec{}
+	}
+	return s.keyCodec
+}
+
+func (s *KV[T1, T2]) vc() Codec {
+	if s.valueCodec == nil {
+		return JSONCodec{}
+	}
+	return s.valueCodec
 }
 
 type WatchEvent[T1 comparable, T2 comparable] struct {
@@ -34,6 +98,26 @@ type WatchEvent[T1 comparable, T2 comparable] struct {
 	Key      T1
 	Value    T2
 	OldValue T2
+
+	// Action classifies the write that produced this event. Unlike Type,
+	// it distinguishes a first write (Create) from a write that replaced
+	// an existing value (Update), mirroring kvdb-style watchers.
+	Action Action
+	// PrevValue is the value the key held immediately before this event.
+	// It is only meaningful when Exists is true for a Create/Update event,
+	// or always for a Delete event (the value that was removed).
+	PrevValue T2
+	// Exists reports whether the key had a prior value at all, so callers
+	// can tell "deleted a real value" from "set the zero value over nothing".
+	Exists bool
+	// Rev is the store-wide revision assigned to this change, as recorded
+	// in the history table. It is 0 for events that predate history being
+	// tracked (e.g. the zero value on an event a caller constructs itself).
+	Rev int64
+
+	// Stats is only populated for a WatchEventBulk event, which carries no
+	// Key/Value: it summarizes the BulkImport/SetMany call that produced it.
+	Stats ImportStats
 }
 
 type WatchEventType int
@@ -41,23 +125,24 @@ type WatchEventType int
 const (
 	WatchEventSet WatchEventType = iota
 	WatchEventDelete
+	// WatchEventLagged is delivered to a subscriber, just before its
+	// channel is closed, when it could not keep up and its overflow
+	// policy is CloseWithError. It carries no Key/Value.
+	WatchEventLagged
+	// WatchEventBulk is delivered once per BulkImport/SetMany call, in
+	// place of one event per affected key, to avoid a notification storm
+	// on large imports. It carries no Key/Value; see WatchEvent.Stats.
+	WatchEventBulk
 )
 
-type watcher[T1 comparable, T2 comparable] struct {
-	id       string
-	key      *T1
-	prefix   *string
-	ch       chan WatchEvent[T1, T2]
-	stopCh   chan struct{}
-	stopped  bool
-	stopOnce sync.Once
-}
+// Action enumerates the kind of write that produced a WatchEvent.
+type Action int
 
-type watcherRegistry[T1 comparable, T2 comparable] struct {
-	mu       sync.RWMutex
-	watchers map[string]*watcher[T1, T2]
-	store    *KV[T1, T2]
-}
+const (
+	Create Action = iota
+	Update
+	Delete
+)
 
 type CancelFunc func()
 
@@ -120,7 +205,7 @@ func getSharedDB() (*sql.DB, error) {
 	return sharedDB, err
 }
 
-func NewAt[T1 comparable, T2 comparable](dbPath string, name string) (*KV[T1, T2], error) {
+func NewAt[T1 comparable, T2 comparable](dbPath string, name string, opts ...Option) (*KV[T1, T2], error) {
 	connStr := fmt.Sprintf("%s?_busy_timeout=10000&_journal=WAL&_sync=NORMAL", dbPath)
 	db, err := sql.Open(DRIVER, connStr)
 	if err != nil {
@@ -131,26 +216,39 @@ func NewAt[T1 comparable, T2 comparable](dbPath string, name string) (*KV[T1, T2
 		db:    db,
 		table: name,
 	}
-
-	store.watchers = &watcherRegistry[T1, T2]{
-		watchers: make(map[string]*watcher[T1, T2]),
-		store:    store,
+	cfg := storeConfig{ttl: defaultTTLConfig()}
+	for _, opt := range opts {
+		opt(&cfg)
 	}
+	store.ttlCfg = cfg.ttl
+	store.coreBackend = cfg.backend
+	store.usingDefaultBackend = cfg.backend == nil
+	if store.coreBackend == nil {
+		store.coreBackend = sqlite.New(db)
+	}
+
+	store.broadcaster = newBroadcaster[T1, T2]()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	// Create table with sanitized name
-	createSQL := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (key PRIMARY KEY, value)", store.table)
-	_, err = store.db.ExecContext(ctx, createSQL)
-	if err != nil {
+	if err := store.coreBackend.EnsureTable(ctx, store.table); err != nil {
+		return nil, err
+	}
+
+	if err := store.initHistory(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := store.ensureExpiryColumn(ctx); err != nil {
 		return nil, err
 	}
+	store.startReaper()
 
 	return store, nil
 }
 
-func New[T1 comparable, T2 comparable](name string) *KV[T1, T2] {
+func New[T1 comparable, T2 comparable](name string, opts ...Option) *KV[T1, T2] {
 	db, err := getSharedDB()
 	if err != nil {
 		panic(err)
@@ -160,21 +258,34 @@ func New[T1 comparable, T2 comparable](name string) *KV[T1, T2] {
 		db:    db,
 		table: name,
 	}
-
-	store.watchers = &watcherRegistry[T1, T2]{
-		watchers: make(map[string]*watcher[T1, T2]),
-		store:    store,
+	cfg := storeConfig{ttl: defaultTTLConfig()}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	store.ttlCfg = cfg.ttl
+	store.coreBackend = cfg.backend
+	store.usingDefaultBackend = cfg.backend == nil
+	if store.coreBackend == nil {
+		store.coreBackend = sqlite.New(db)
 	}
 
+	store.broadcaster = newBroadcaster[T1, T2]()
+
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	// Create table with sanitized name
-	createSQL := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (key PRIMARY KEY, value)", store.table)
-	_, err = store.db.ExecContext(ctx, createSQL)
-	if err != nil {
+	if err := store.coreBackend.EnsureTable(ctx, store.table); err != nil {
+		panic(err)
+	}
+
+	if err := store.initHistory(ctx); err != nil {
+		panic(err)
+	}
+
+	if err := store.ensureExpiryColumn(ctx); err != nil {
 		panic(err)
 	}
+	store.startReaper()
 
 	return store
 }
@@ -183,37 +294,50 @@ func (s *KV[T1, T2]) TrySet(key T1, value T2) (T2, error) {
 	// Get old value for watch events
 	oldValue, hadOldValue := s.getOldValue(key)
 
-	// Serialize the key to JSON
-	keyBytes, err := json.Marshal(key)
+	keyBytes, err := s.kc().Marshal(key)
 	if err != nil {
 		return value, fmt.Errorf("failed to marshal key: %w", err)
 	}
 
-	// Serialize the value to JSON
-	valueBytes, err := json.Marshal(value)
+	valueBytes, err := s.vc().Marshal(value)
 	if err != nil {
 		return value, fmt.Errorf("failed to marshal value: %w", err)
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	sql := fmt.Sprintf("INSERT OR REPLACE INTO %s (key, value) VALUES (?, ?)", s.table)
-	_, err = s.db.ExecContext(ctx, sql, string(keyBytes), string(valueBytes))
-	if err != nil {
-		return value, err
+	// The key column keeps TEXT storage class regardless of codec so it
+	// stays comparable with every existing key = ?/>=?/LIKE clause; the
+	// value column is stored as its raw codec bytes (BLOB storage class),
+	// which is what lets binary codecs skip JSON's UTF-8/base64 overhead.
+	if s.hasIndexes() {
+		if err := s.setWithIndexes(ctx, key, value, keyBytes, valueBytes); err != nil {
+			return value, err
+		}
+	} else {
+		if err := s.coreBackend.Put(ctx, s.table, string(keyBytes), backend.Row{Value: valueBytes}); err != nil {
+			return value, err
+		}
 	}
 
 	// Notify watchers
-	if s.watchers != nil {
+	if s.broadcaster != nil {
 		event := WatchEvent[T1, T2]{
-			Type:  WatchEventSet,
-			Key:   key,
-			Value: value,
+			Type:   WatchEventSet,
+			Key:    key,
+			Value:  value,
+			Action: Create,
+			Exists: hadOldValue,
 		}
 		if hadOldValue {
 			event.OldValue = oldValue
+			event.PrevValue = oldValue
+			event.Action = Update
+		}
+		if rev, histErr := s.appendHistory(key, oldValue, value, event.Action); histErr == nil {
+			event.Rev = rev
 		}
-		s.watchers.notify(key, event)
+		s.broadcaster.publish(keyString(key), event)
 	}
 
 	return value, nil
@@ -221,25 +345,25 @@ func (s *KV[T1, T2]) TrySet(key T1, value T2) (T2, error) {
 
 func (s *KV[T1, T2]) TryGet(key T1) (T2, error) {
 	var v T2
-	var valueStr string
+	var valueBytes []byte
 
-	// Serialize the key to JSON
-	keyBytes, err := json.Marshal(key)
+	keyBytes, err := s.kc().Marshal(key)
 	if err != nil {
 		return v, fmt.Errorf("failed to marshal key: %w", err)
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
-	sql := fmt.Sprintf("SELECT value FROM %s WHERE key = ?", s.table)
-	err = s.db.QueryRowContext(ctx, sql, string(keyBytes)).Scan(&valueStr)
+	row, ok, err := s.coreBackend.Get(ctx, s.table, string(keyBytes))
 	if err != nil {
 		return v, err
 	}
+	if !ok {
+		return v, sql.ErrNoRows
+	}
+	valueBytes = row.Value
 
-	// Deserialize from JSON
-	err = json.Unmarshal([]byte(valueStr), &v)
-	if err != nil {
+	if err := s.vc().Unmarshal(valueBytes, &v); err != nil {
 		return v, fmt.Errorf("failed to unmarshal value: %w", err)
 	}
 
@@ -247,54 +371,62 @@ func (s *KV[T1, T2]) TryGet(key T1) (T2, error) {
 }
 
 func (s *KV[T1, T2]) TryHas(key T1) (bool, error) {
-	// Serialize the key to JSON
-	keyBytes, err := json.Marshal(key)
+	keyBytes, err := s.kc().Marshal(key)
 	if err != nil {
 		return false, fmt.Errorf("failed to marshal key: %w", err)
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
-	query := fmt.Sprintf("SELECT 1 FROM %s WHERE key = ? LIMIT 1", s.table)
-	var exists int
-	err = s.db.QueryRowContext(ctx, query, string(keyBytes)).Scan(&exists)
-	if err == nil {
-		return true, nil
-	}
-	if err == sql.ErrNoRows {
-		return false, nil
-	}
-	return false, err
+	_, ok, err := s.coreBackend.Get(ctx, s.table, string(keyBytes))
+	return ok, err
 }
 
 func (s *KV[T1, T2]) TryDelete(key T1) error {
 	// Get old value for watch events
 	oldValue, hadOldValue := s.getOldValue(key)
 
-	// Serialize the key to JSON
-	keyBytes, err := json.Marshal(key)
+	keyBytes, err := s.kc().Marshal(key)
 	if err != nil {
 		return fmt.Errorf("failed to marshal key: %w", err)
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
-	sql := fmt.Sprintf("DELETE FROM %s WHERE key = ?", s.table)
-	result, err := s.db.ExecContext(ctx, sql, string(keyBytes))
-	if err != nil {
-		return err
+
+	var rowsAffected int64
+	if s.hasIndexes() {
+		n, err := s.deleteWithIndexes(ctx, keyBytes)
+		if err != nil {
+			return err
+		}
+		rowsAffected = n
+	} else {
+		existed, err := s.coreBackend.Delete(ctx, s.table, string(keyBytes))
+		if err != nil {
+			return err
+		}
+		if existed {
+			rowsAffected = 1
+		}
 	}
 
 	// Only notify if something was actually deleted
-	if s.watchers != nil && hadOldValue {
-		rowsAffected, _ := result.RowsAffected()
+	if s.broadcaster != nil && hadOldValue {
 		if rowsAffected > 0 {
 			event := WatchEvent[T1, T2]{
-				Type:     WatchEventDelete,
-				Key:      key,
-				OldValue: oldValue,
+				Type:      WatchEventDelete,
+				Key:       key,
+				OldValue:  oldValue,
+				Action:    Delete,
+				PrevValue: oldValue,
+				Exists:    true,
+			}
+			var zero T2
+			if rev, histErr := s.appendHistory(key, oldValue, zero, Delete); histErr == nil {
+				event.Rev = rev
 			}
-			s.watchers.notify(key, event)
+			s.broadcaster.publish(keyString(key), event)
 		}
 	}
 
@@ -302,9 +434,12 @@ func (s *KV[T1, T2]) TryDelete(key T1) error {
 }
 
 func (s *KV[T1, T2]) TryForEachReverse(fn func(key T1, value T2)) error {
+	if err := s.requireDefaultBackend("TryForEachReverse"); err != nil {
+		return err
+	}
 	ctx := context.Background()
-	sql := fmt.Sprintf("SELECT key, value FROM %s order by key desc", s.table)
-	rows, err := s.db.QueryContext(ctx, sql)
+	sql := fmt.Sprintf("SELECT key, value FROM %s WHERE expiry IS NULL OR expiry > ? order by key desc", s.table)
+	rows, err := s.db.QueryContext(ctx, sql, time.Now().UnixNano())
 	if err != nil {
 		return err
 	}
@@ -319,13 +454,11 @@ func (s *KV[T1, T2]) TryForEachReverse(fn func(key T1, value T2)) error {
 			return err
 		}
 
-		// Deserialize key from JSON
-		if err := json.Unmarshal([]byte(keyStr), &k); err != nil {
+		if err := s.kc().Unmarshal([]byte(keyStr), &k); err != nil {
 			return fmt.Errorf("failed to unmarshal key: %w", err)
 		}
 
-		// Deserialize value from JSON
-		if err := json.Unmarshal([]byte(valueStr), &v); err != nil {
+		if err := s.vc().Unmarshal([]byte(valueStr), &v); err != nil {
 			return fmt.Errorf("failed to unmarshal value: %w", err)
 		}
 
@@ -335,9 +468,12 @@ func (s *KV[T1, T2]) TryForEachReverse(fn func(key T1, value T2)) error {
 }
 
 func (s *KV[T1, T2]) TryForEach(fn func(key T1, value T2)) error {
+	if err := s.requireDefaultBackend("TryForEach"); err != nil {
+		return err
+	}
 	ctx := context.Background()
-	sql := fmt.Sprintf("SELECT key, value FROM %s order by key", s.table)
-	rows, err := s.db.QueryContext(ctx, sql)
+	sql := fmt.Sprintf("SELECT key, value FROM %s WHERE expiry IS NULL OR expiry > ? order by key", s.table)
+	rows, err := s.db.QueryContext(ctx, sql, time.Now().UnixNano())
 	if err != nil {
 		return err
 	}
@@ -352,13 +488,11 @@ func (s *KV[T1, T2]) TryForEach(fn func(key T1, value T2)) error {
 			return err
 		}
 
-		// Deserialize key from JSON
-		if err := json.Unmarshal([]byte(keyStr), &k); err != nil {
+		if err := s.kc().Unmarshal([]byte(keyStr), &k); err != nil {
 			return fmt.Errorf("failed to unmarshal key: %w", err)
 		}
 
-		// Deserialize value from JSON
-		if err := json.Unmarshal([]byte(valueStr), &v); err != nil {
+		if err := s.vc().Unmarshal([]byte(valueStr), &v); err != nil {
 			return fmt.Errorf("failed to unmarshal value: %w", err)
 		}
 
@@ -370,62 +504,56 @@ func (s *KV[T1, T2]) TryForEach(fn func(key T1, value T2)) error {
 func (s *KV[T1, T2]) TryClear() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
-	sql := fmt.Sprintf("DELETE FROM %s", s.table)
-	_, err := s.db.ExecContext(ctx, sql)
 
 	// Clear notifies all watchers with delete events
 	// For simplicity, we're not sending individual delete events for each key
 
-	return err
+	if s.hasIndexes() {
+		return s.clearWithIndexes(ctx)
+	}
+	return s.coreBackend.Clear(ctx, s.table)
 }
 
-// Watch monitors changes to a specific key
+// Watch monitors changes to a specific key. The returned channel is closed
+// when cancel is called; it has no associated context, so it is only ever
+// removed explicitly.
 func (s *KV[T1, T2]) Watch(key T1) (<-chan WatchEvent[T1, T2], CancelFunc) {
-	ch := make(chan WatchEvent[T1, T2], 10)
+	cfg := defaultWatchConfig()
+	return s.broadcaster.subscribe(nil, cfg, exactMatcher(keyString(key)))
+}
 
-	w := &watcher[T1, T2]{
-		id:     fmt.Sprintf("%v_%d", key, time.Now().UnixNano()),
-		key:    &key,
-		ch:     ch,
-		stopCh: make(chan struct{}),
+// WatchPrefix monitors changes to every key whose string form starts with
+// prefix. The subscription is automatically removed when ctx is canceled,
+// in addition to calling the returned CancelFunc.
+func (s *KV[T1, T2]) WatchPrefix(ctx context.Context, prefix T1, opts ...WatchOption) (<-chan WatchEvent[T1, T2], CancelFunc) {
+	cfg := defaultWatchConfig()
+	for _, opt := range opts {
+		opt(&cfg)
 	}
+	return s.broadcaster.subscribe(ctx, cfg, prefixMatcher(keyString(prefix)))
+}
 
-	s.watchers.mu.Lock()
-	s.watchers.watchers[w.id] = w
-	s.watchers.mu.Unlock()
-
-	return ch, func() {
-		w.stop()
-		s.watchers.mu.Lock()
-		delete(s.watchers.watchers, w.id)
-		s.watchers.mu.Unlock()
-		close(ch)
+// WatchRange monitors changes to every key k with lo <= k < hi, comparing
+// keys by their string form. The subscription is automatically removed
+// when ctx is canceled, in addition to calling the returned CancelFunc.
+func (s *KV[T1, T2]) WatchRange(ctx context.Context, lo, hi T1, opts ...WatchOption) (<-chan WatchEvent[T1, T2], CancelFunc) {
+	cfg := defaultWatchConfig()
+	for _, opt := range opts {
+		opt(&cfg)
 	}
+	return s.broadcaster.subscribe(ctx, cfg, rangeMatcher(keyString(lo), keyString(hi)))
 }
 
-// // WatchPrefix monitors changes to keys with a specific prefix
-// func (s *KV[T1, T2]) WatchPrefix(prefix string) (<-chan WatchEvent[T1, T2], CancelFunc) {
-// 	ch := make(chan WatchEvent[T1, T2], 10)
-//
-// 	w := &watcher[T1, T2]{
-// 		id:     fmt.Sprintf("prefix_%s_%d", prefix, time.Now().UnixNano()),
-// 		prefix: &prefix,
-// 		ch:     ch,
-// 		stopCh: make(chan struct{}),
-// 	}
-//
-// 	s.watchers.mu.Lock()
-// 	s.watchers.watchers[w.id] = w
-// 	s.watchers.mu.Unlock()
-//
-// 	return ch, func() {
-// 		w.stop()
-// 		s.watchers.mu.Lock()
-// 		delete(s.watchers.watchers, w.id)
-// 		s.watchers.mu.Unlock()
-// 		close(ch)
-// 	}
-// }
+// WatchAll monitors every Set/Delete on the store. The subscription is
+// automatically removed when ctx is canceled, in addition to calling the
+// returned CancelFunc.
+func (s *KV[T1, T2]) WatchAll(ctx context.Context, opts ...WatchOption) (<-chan WatchEvent[T1, T2], CancelFunc) {
+	cfg := defaultWatchConfig()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return s.broadcaster.subscribe(ctx, cfg, allMatcher())
+}
 
 // func (s *KV[T1, T2]) SetE1(key T1, value T2) T2 {
 // 	out, err := s.TrySet(key, value)
@@ -526,9 +654,12 @@ func (s *KV[T1, T2]) Clear() {
 
 // All is an iterator over all elements starting from the head of l.
 func (s *KV[T1, T2]) Backward(yield func(T1, T2) bool) {
+	if s.requireDefaultBackend("Backward") != nil {
+		return
+	}
 	ctx := context.Background()
-	sql := fmt.Sprintf("SELECT key, value FROM %s ORDER BY key desc", s.table)
-	rows, err := s.db.QueryContext(ctx, sql)
+	sql := fmt.Sprintf("SELECT key, value FROM %s WHERE expiry IS NULL OR expiry > ? ORDER BY key desc", s.table)
+	rows, err := s.db.QueryContext(ctx, sql, time.Now().UnixNano())
 	if err != nil {
 		return
 	}
@@ -543,11 +674,11 @@ func (s *KV[T1, T2]) Backward(yield func(T1, T2) bool) {
 			panic(err)
 		}
 
-		if err := json.Unmarshal([]byte(keyStr), &k); err != nil {
+		if err := s.kc().Unmarshal([]byte(keyStr), &k); err != nil {
 			panic(err)
 		}
 
-		if err := json.Unmarshal([]byte(valueStr), &v); err != nil {
+		if err := s.vc().Unmarshal([]byte(valueStr), &v); err != nil {
 			panic(err)
 		}
 
@@ -559,9 +690,12 @@ func (s *KV[T1, T2]) Backward(yield func(T1, T2) bool) {
 
 // All is an iterator over all elements starting from the head of l.
 func (s *KV[T1, T2]) All(yield func(T1, T2) bool) {
+	if s.requireDefaultBackend("All") != nil {
+		return
+	}
 	ctx := context.Background()
-	sql := fmt.Sprintf("SELECT key, value FROM %s ORDER BY key", s.table)
-	rows, err := s.db.QueryContext(ctx, sql)
+	sql := fmt.Sprintf("SELECT key, value FROM %s WHERE expiry IS NULL OR expiry > ? ORDER BY key", s.table)
+	rows, err := s.db.QueryContext(ctx, sql, time.Now().UnixNano())
 	if err != nil {
 		return
 	}
@@ -576,11 +710,11 @@ func (s *KV[T1, T2]) All(yield func(T1, T2) bool) {
 			panic(err)
 		}
 
-		if err := json.Unmarshal([]byte(keyStr), &k); err != nil {
+		if err := s.kc().Unmarshal([]byte(keyStr), &k); err != nil {
 			panic(err)
 		}
 
-		if err := json.Unmarshal([]byte(valueStr), &v); err != nil {
+		if err := s.vc().Unmarshal([]byte(valueStr), &v); err != nil {
 			panic(err)
 		}
 
@@ -593,9 +727,12 @@ func (s *KV[T1, T2]) All(yield func(T1, T2) bool) {
 // Iter returns an iterator for use with Go 1.23+ range-over-func
 func (s *KV[T1, T2]) Iter() func(func(T1, T2) bool) {
 	return func(yield func(T1, T2) bool) {
+		if s.requireDefaultBackend("Iter") != nil {
+			return
+		}
 		ctx := context.Background()
-		sql := fmt.Sprintf("SELECT key, value FROM %s ORDER BY key", s.table)
-		rows, err := s.db.QueryContext(ctx, sql)
+		sql := fmt.Sprintf("SELECT key, value FROM %s WHERE expiry IS NULL OR expiry > ? ORDER BY key", s.table)
+		rows, err := s.db.QueryContext(ctx, sql, time.Now().UnixNano())
 		if err != nil {
 			return
 		}
@@ -610,11 +747,11 @@ func (s *KV[T1, T2]) Iter() func(func(T1, T2) bool) {
 				panic(err)
 			}
 
-			if err := json.Unmarshal([]byte(keyStr), &k); err != nil {
+			if err := s.kc().Unmarshal([]byte(keyStr), &k); err != nil {
 				panic(err)
 			}
 
-			if err := json.Unmarshal([]byte(valueStr), &v); err != nil {
+			if err := s.vc().Unmarshal([]byte(valueStr), &v); err != nil {
 				panic(err)
 			}
 
@@ -628,9 +765,12 @@ func (s *KV[T1, T2]) Iter() func(func(T1, T2) bool) {
 // IterReverse returns a reverse iterator for use with Go 1.23+ range-over-func
 func (s *KV[T1, T2]) IterReverse() func(func(T1, T2) bool) {
 	return func(yield func(T1, T2) bool) {
+		if s.requireDefaultBackend("IterReverse") != nil {
+			return
+		}
 		ctx := context.Background()
-		sql := fmt.Sprintf("SELECT key, value FROM %s ORDER BY key DESC", s.table)
-		rows, err := s.db.QueryContext(ctx, sql)
+		sql := fmt.Sprintf("SELECT key, value FROM %s WHERE expiry IS NULL OR expiry > ? ORDER BY key DESC", s.table)
+		rows, err := s.db.QueryContext(ctx, sql, time.Now().UnixNano())
 		if err != nil {
 			return
 		}
@@ -645,11 +785,11 @@ func (s *KV[T1, T2]) IterReverse() func(func(T1, T2) bool) {
 				return
 			}
 
-			if err := json.Unmarshal([]byte(keyStr), &k); err != nil {
+			if err := s.kc().Unmarshal([]byte(keyStr), &k); err != nil {
 				return
 			}
 
-			if err := json.Unmarshal([]byte(valueStr), &v); err != nil {
+			if err := s.vc().Unmarshal([]byte(valueStr), &v); err != nil {
 				return
 			}
 
@@ -662,26 +802,19 @@ func (s *KV[T1, T2]) IterReverse() func(func(T1, T2) bool) {
 
 // StopAllWatchers stops all active watchers
 func (s *KV[T1, T2]) StopAllWatchers() {
-	if s.watchers == nil {
+	if s.broadcaster == nil {
 		return
 	}
-
-	s.watchers.mu.Lock()
-	defer s.watchers.mu.Unlock()
-
-	for _, w := range s.watchers.watchers {
-		w.stop()
-	}
-	s.watchers.watchers = make(map[string]*watcher[T1, T2])
+	s.broadcaster.stopAll()
 }
 
-// Helper method to get old value before modification
+// Helper method to get old value before modification. It reads through
+// s.coreBackend rather than raw SQL against s.db, so it reports the right
+// answer on a WithBackend override too.
 func (s *KV[T1, T2]) getOldValue(key T1) (T2, bool) {
 	var oldValue T2
-	var valueStr string
 
-	// Serialize the key to JSON
-	keyBytes, err := json.Marshal(key)
+	keyBytes, err := s.kc().Marshal(key)
 	if err != nil {
 		return oldValue, false
 	}
@@ -689,61 +822,15 @@ func (s *KV[T1, T2]) getOldValue(key T1) (T2, bool) {
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
 	defer cancel()
 
-	sql := fmt.Sprintf("SELECT value FROM %s WHERE key = ?", s.table)
-	err = s.db.QueryRowContext(ctx, sql, string(keyBytes)).Scan(&valueStr)
-	if err != nil {
+	row, ok, err := s.coreBackend.Get(ctx, s.table, string(keyBytes))
+	if err != nil || !ok {
 		return oldValue, false
 	}
 
-	// Deserialize from JSON
-	err = json.Unmarshal([]byte(valueStr), &oldValue)
-	if err != nil {
+	if err := s.vc().Unmarshal(row.Value, &oldValue); err != nil {
 		return oldValue, false
 	}
 
 	return oldValue, true
 }
 
-// stop safely stops a watcher
-func (w *watcher[T1, T2]) stop() {
-	w.stopOnce.Do(func() {
-		w.stopped = true
-		close(w.stopCh)
-	})
-}
-
-// notify sends events to matching watchers
-func (r *watcherRegistry[T1, T2]) notify(key T1, event WatchEvent[T1, T2]) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	keyStr := fmt.Sprintf("%v", key)
-
-	for _, w := range r.watchers {
-		if w.stopped {
-			continue
-		}
-
-		// Check if this watcher matches
-		matches := false
-		if w.key != nil {
-			// Exact key match
-			matches = fmt.Sprintf("%v", *w.key) == keyStr
-		} else if w.prefix != nil {
-			// Prefix match
-			matches = strings.HasPrefix(keyStr, *w.prefix)
-		}
-
-		if matches {
-			select {
-			case w.ch <- event:
-				// Event sent successfully
-			case <-w.stopCh:
-				// Watcher stopped
-
-				// case <-time.After(100 * time.Millisecond):
-				// 	// Don't block if channel is full
-			}
-		}
-	}
-}
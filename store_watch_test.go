@@ -1,6 +1,7 @@
 package kvstore_test
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -111,7 +112,7 @@ func TestWatchPrefix(t *testing.T) {
 	}
 	
 	// Watch all keys with prefix "user:" - returns channel and cancel func
-	eventCh, cancel := store.WatchPrefix("user:")
+	eventCh, cancel := store.WatchPrefix(context.Background(), "user:")
 	defer cancel()
 	
 	// Test multiple keys with the same prefix
@@ -172,7 +173,7 @@ func TestMultipleWatchers(t *testing.T) {
 	ch2, cancel2 := store.Watch("key1")
 	defer cancel2()
 	
-	ch3, cancel3 := store.WatchPrefix("key")
+	ch3, cancel3 := store.WatchPrefix(context.Background(), "key")
 	defer cancel3()
 	
 	// Set key1 - all watchers should receive the event
@@ -256,7 +257,7 @@ func TestStopAllWatchers(t *testing.T) {
 	// Create multiple watchers
 	ch1, _ := store.Watch("key1")
 	ch2, _ := store.Watch("key2")
-	ch3, _ := store.WatchPrefix("key")
+	ch3, _ := store.WatchPrefix(context.Background(), "key")
 	
 	// Stop all watchers
 	store.StopAllWatchers()
@@ -319,4 +320,116 @@ func TestWatchWithCleanup(t *testing.T) {
 	if val != "value1" {
 		t.Errorf("Expected value1, got %v", val)
 	}
+}
+
+func TestWatchAction(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_watch_action.db")
+
+	store, err := kvstore.NewAt[string, string](dbPath, "test_watch_action")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	eventCh, cancel := store.Watch("key1")
+	defer cancel()
+
+	// First write is a Create: no prior value exists.
+	store.Set("key1", "value1")
+
+	select {
+	case event := <-eventCh:
+		if event.Action != kvstore.Create {
+			t.Errorf("Expected Create action, got %v", event.Action)
+		}
+		if event.Exists {
+			t.Errorf("Expected Exists=false on first write")
+		}
+	case <-time.After(1 * time.Second):
+		t.Fatal("Timeout waiting for create event")
+	}
+
+	// Second write to the same key is an Update: PrevValue is populated.
+	store.Set("key1", "value2")
+
+	select {
+	case event := <-eventCh:
+		if event.Action != kvstore.Update {
+			t.Errorf("Expected Update action, got %v", event.Action)
+		}
+		if !event.Exists {
+			t.Errorf("Expected Exists=true on update")
+		}
+		if event.PrevValue != "value1" {
+			t.Errorf("Expected PrevValue=value1, got %v", event.PrevValue)
+		}
+	case <-time.After(1 * time.Second):
+		t.Fatal("Timeout waiting for update event")
+	}
+
+	// Delete carries PrevValue so it's distinguishable from setting a zero value.
+	store.Delete("key1")
+
+	select {
+	case event := <-eventCh:
+		if event.Action != kvstore.Delete {
+			t.Errorf("Expected Delete action, got %v", event.Action)
+		}
+		if !event.Exists {
+			t.Errorf("Expected Exists=true on delete")
+		}
+		if event.PrevValue != "value2" {
+			t.Errorf("Expected PrevValue=value2, got %v", event.PrevValue)
+		}
+	case <-time.After(1 * time.Second):
+		t.Fatal("Timeout waiting for delete event")
+	}
+}
+
+func TestWatchRangeAndCtxCancel(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_watch_range.db")
+
+	store, err := kvstore.NewAt[string, string](dbPath, "test_watch_range")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	eventCh, _ := store.WatchRange(ctx, "b", "d")
+
+	store.Set("a1", "out of range")
+	store.Set("b1", "in range")
+
+	select {
+	case event := <-eventCh:
+		if event.Key != "b1" {
+			t.Fatalf("Expected b1, got %v", event.Key)
+		}
+	case <-time.After(1 * time.Second):
+		t.Fatal("Timeout waiting for in-range event")
+	}
+
+	select {
+	case event, ok := <-eventCh:
+		if ok {
+			t.Fatalf("Unexpected event outside range: %v", event)
+		}
+	case <-time.After(200 * time.Millisecond):
+		// Expected: a1 never matched the range
+	}
+
+	// Canceling ctx should unregister the subscription and close the channel.
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	store.Set("c1", "in range but too late")
+
+	select {
+	case _, ok := <-eventCh:
+		if ok {
+			t.Fatal("Expected channel closed after context cancellation")
+		}
+	case <-time.After(1 * time.Second):
+		t.Fatal("Timeout waiting for channel close after cancel")
+	}
 }
\ No newline at end of file
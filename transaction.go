@@ -14,6 +14,19 @@ type Tx[T1 comparable, T2 comparable] struct {
 	tx    *sql.Tx
 	table string
 	store *KV[T1, T2]
+
+	// pendingEvents accumulates watch events raised by bulk range
+	// operations, so they can be published only after the transaction
+	// actually commits.
+	pendingEvents []WatchEvent[T1, T2]
+
+	// savepointSeq names the savepoints Nested creates, so concurrent
+	// nesting depths within the same Tx never collide.
+	savepointSeq int
+
+	// savepointMarks records len(pendingEvents) at each Savepoint, so
+	// RollbackTo can drop the events of the writes it undoes.
+	savepointMarks map[string]int
 }
 
 // Transaction executes a function within a database transaction
@@ -36,11 +49,15 @@ type Tx[T1 comparable, T2 comparable] struct {
 //       return nil // Success - all changes are saved
 //   })
 func (s *KV[T1, T2]) Transaction(fn func(tx *Tx[T1, T2]) error) error {
+	if err := s.requireDefaultBackend("Transaction"); err != nil {
+		return err
+	}
+
 	// Start a database transaction with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
-	
-	sqlTx, err := s.db.BeginTx(ctx, nil)
+
+	sqlTx, err := beginImmediate(ctx, s.db)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -67,26 +84,88 @@ func (s *KV[T1, T2]) Transaction(fn func(tx *Tx[T1, T2]) error) error {
 	if err := sqlTx.Commit(); err != nil {
 		return fmt.Errorf("commit failed: %w", err)
 	}
-	
+
+	// Only now that the transaction is durable do we record history and
+	// notify watchers, so observers never see events for uncommitted work.
+	s.publishPendingEvents(tx)
+
 	return nil
 }
 
+// publishPendingEvents appends a history entry for every event tx
+// accumulated (via Tx.Set/Tx.Delete/DeleteRange/DeletePrefix), then
+// publishes it to watchers, in that order. Callers must only invoke this
+// after tx's underlying *sql.Tx has durably committed, so observers never
+// see events for uncommitted work; both Transaction and TxnOps call it
+// from their own commit path.
+func (s *KV[T1, T2]) publishPendingEvents(tx *Tx[T1, T2]) {
+	for _, event := range tx.pendingEvents {
+		newValue := event.Value
+		if event.Action == Delete {
+			var zero T2
+			newValue = zero
+		}
+		if rev, histErr := s.appendHistory(event.Key, event.PrevValue, newValue, event.Action); histErr == nil {
+			event.Rev = rev
+		}
+		if s.broadcaster != nil {
+			s.broadcaster.publish(keyString(event.Key), event)
+		}
+	}
+}
+
 // Set stores a key-value pair within the transaction
-// Changes are not visible outside the transaction until it commits
+// Changes are not visible outside the transaction until it commits, at
+// which point a history entry and watch event are recorded for it, the
+// same as KV.TrySet.
 func (tx *Tx[T1, T2]) Set(key T1, value T2) error {
 	keyBytes, err := json.Marshal(key)
 	if err != nil {
 		return fmt.Errorf("failed to marshal key: %w", err)
 	}
-	
+
 	valueBytes, err := json.Marshal(value)
 	if err != nil {
 		return fmt.Errorf("failed to marshal value: %w", err)
 	}
-	
+
+	oldValue, hadOldValue := tx.getOldValue(key)
+
 	query := fmt.Sprintf("INSERT OR REPLACE INTO %s (key, value) VALUES (?, ?)", tx.table)
-	_, err = tx.tx.Exec(query, string(keyBytes), string(valueBytes))
-	return err
+	if _, err := tx.tx.Exec(query, string(keyBytes), string(valueBytes)); err != nil {
+		return err
+	}
+
+	if err := tx.syncIndexSet(key, value, keyBytes); err != nil {
+		return err
+	}
+
+	event := WatchEvent[T1, T2]{
+		Type:   WatchEventSet,
+		Key:    key,
+		Value:  value,
+		Action: Create,
+		Exists: hadOldValue,
+	}
+	if hadOldValue {
+		event.OldValue = oldValue
+		event.PrevValue = oldValue
+		event.Action = Update
+	}
+	tx.pendingEvents = append(tx.pendingEvents, event)
+	return nil
+}
+
+// getOldValue returns key's value as tx currently sees it (ok is false if
+// absent), mirroring KV.getOldValue but reading through tx's own *sql.Tx
+// so Set/Delete see the same in-flight snapshot as every other op in tx.
+func (tx *Tx[T1, T2]) getOldValue(key T1) (T2, bool) {
+	value, err := tx.Get(key)
+	if err != nil {
+		var zero T2
+		return zero, false
+	}
+	return value, true
 }
 
 // Get retrieves a value by key within the transaction
@@ -120,10 +199,32 @@ func (tx *Tx[T1, T2]) Delete(key T1) error {
 	if err != nil {
 		return fmt.Errorf("failed to marshal key: %w", err)
 	}
-	
+
+	oldValue, hadOldValue := tx.getOldValue(key)
+
 	query := fmt.Sprintf("DELETE FROM %s WHERE key = ?", tx.table)
-	_, err = tx.tx.Exec(query, string(keyBytes))
-	return err
+	result, err := tx.tx.Exec(query, string(keyBytes))
+	if err != nil {
+		return err
+	}
+
+	if err := tx.syncIndexDelete(keyBytes); err != nil {
+		return err
+	}
+
+	if hadOldValue {
+		if rowsAffected, _ := result.RowsAffected(); rowsAffected > 0 {
+			tx.pendingEvents = append(tx.pendingEvents, WatchEvent[T1, T2]{
+				Type:      WatchEventDelete,
+				Key:       key,
+				OldValue:  oldValue,
+				Action:    Delete,
+				PrevValue: oldValue,
+				Exists:    true,
+			})
+		}
+	}
+	return nil
 }
 
 // Has checks if a key exists within the transaction
@@ -182,8 +283,51 @@ func (tx *Tx[T1, T2]) ForEach(fn func(key T1, value T2) error) error {
 // Clear removes all key-value pairs within the transaction
 func (tx *Tx[T1, T2]) Clear() error {
 	query := fmt.Sprintf("DELETE FROM %s", tx.table)
-	_, err := tx.tx.Exec(query)
-	return err
+	if _, err := tx.tx.Exec(query); err != nil {
+		return err
+	}
+	return tx.syncIndexClear()
+}
+
+// CompareAndSwap replaces key's value with new within tx, but only if its
+// current value (as seen by tx) equals old. It reports whether the swap
+// happened. Unlike the top-level KV.CompareAndSwap, this participates in
+// tx's snapshot: it is only durable, and only visible to other readers, if
+// tx itself commits.
+func (tx *Tx[T1, T2]) CompareAndSwap(key T1, old, new T2) (bool, error) {
+	current, err := tx.Get(key)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+	if current != old {
+		return false, nil
+	}
+	if err := tx.Set(key, new); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// CompareAndDelete removes key within tx, but only if its current value
+// (as seen by tx) equals old. It reports whether the delete happened.
+func (tx *Tx[T1, T2]) CompareAndDelete(key T1, old T2) (bool, error) {
+	current, err := tx.Get(key)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+	if current != old {
+		return false, nil
+	}
+	if err := tx.Delete(key); err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
 // GetOr retrieves a value by key, returning a default if not found
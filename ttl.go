@@ -0,0 +1,236 @@
+package kvstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	defaultReaperInterval  = 5 * time.Second
+	defaultReaperBatchSize = 1000
+)
+
+type ttlConfig struct {
+	reaperInterval  time.Duration
+	reaperBatchSize int
+}
+
+func defaultTTLConfig() ttlConfig {
+	return ttlConfig{
+		reaperInterval:  defaultReaperInterval,
+		reaperBatchSize: defaultReaperBatchSize,
+	}
+}
+
+// WithReaperInterval overrides how often the background reaper scans for
+// expired keys. Defaults to 5s.
+func WithReaperInterval(d time.Duration) Option {
+	return func(c *storeConfig) { c.ttl.reaperInterval = d }
+}
+
+// WithReaperBatchSize overrides how many expired keys the reaper deletes
+// per sweep. Defaults to 1000.
+func WithReaperBatchSize(n int) Option {
+	return func(c *storeConfig) { c.ttl.reaperBatchSize = n }
+}
+
+// ensureExpiryColumn adds the nullable expiry column (unix nanoseconds) to
+// an existing table, for stores created before TTL support existed. SQLite
+// has no "ADD COLUMN IF NOT EXISTS", so a failure here is only a real error
+// if it isn't the expected "duplicate column name" from the column already
+// existing.
+func (s *KV[T1, T2]) ensureExpiryColumn(ctx context.Context) error {
+	if !s.usingDefaultBackend {
+		// A non-default Backend keeps its rows outside db, so there is no
+		// table here to alter.
+		return nil
+	}
+	alterSQL := fmt.Sprintf("ALTER TABLE %s ADD COLUMN expiry INTEGER", s.table)
+	_, err := s.db.ExecContext(ctx, alterSQL)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return err
+	}
+	return nil
+}
+
+// SetTTL stores value at key and expires it after ttl elapses.
+func (s *KV[T1, T2]) SetTTL(key T1, value T2, ttl time.Duration) error {
+	return s.SetWithExpiry(key, value, time.Now().Add(ttl))
+}
+
+// SetWithExpiry stores value at key and expires it at the given time. On a
+// store with secondary indexes, the write and every index's upsert run
+// inside one transaction, the same as TrySet.
+func (s *KV[T1, T2]) SetWithExpiry(key T1, value T2, at time.Time) error {
+	// Get old value for watch events
+	oldValue, hadOldValue := s.getOldValue(key)
+
+	// Serialize the key to JSON
+	keyBytes, err := json.Marshal(key)
+	if err != nil {
+		return fmt.Errorf("failed to marshal key: %w", err)
+	}
+
+	// Serialize the value to JSON
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal value: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if s.hasIndexes() {
+		if err := s.setWithExpiryAndIndexes(ctx, key, value, keyBytes, valueBytes, at.UnixNano()); err != nil {
+			return err
+		}
+	} else {
+		sql := fmt.Sprintf("INSERT OR REPLACE INTO %s (key, value, expiry) VALUES (?, ?, ?)", s.table)
+		if _, err := s.db.ExecContext(ctx, sql, string(keyBytes), string(valueBytes), at.UnixNano()); err != nil {
+			return err
+		}
+	}
+
+	// Notify watchers
+	if s.broadcaster != nil {
+		event := WatchEvent[T1, T2]{
+			Type:   WatchEventSet,
+			Key:    key,
+			Value:  value,
+			Action: Create,
+			Exists: hadOldValue,
+		}
+		if hadOldValue {
+			event.OldValue = oldValue
+			event.PrevValue = oldValue
+			event.Action = Update
+		}
+		if rev, histErr := s.appendHistory(key, oldValue, value, event.Action); histErr == nil {
+			event.Rev = rev
+		}
+		s.broadcaster.publish(keyString(key), event)
+	}
+
+	return nil
+}
+
+// TTL returns the time remaining before key expires, and whether key has
+// an expiry set at all (ok is false for an absent key or one with no TTL).
+func (s *KV[T1, T2]) TTL(key T1) (ttl time.Duration, ok bool) {
+	keyBytes, err := json.Marshal(key)
+	if err != nil {
+		return 0, false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	query := fmt.Sprintf("SELECT expiry FROM %s WHERE key = ?", s.table)
+	var expiry sql.NullInt64
+	if err := s.db.QueryRowContext(ctx, query, string(keyBytes)).Scan(&expiry); err != nil {
+		return 0, false
+	}
+	if !expiry.Valid {
+		return 0, false
+	}
+	return time.Until(time.Unix(0, expiry.Int64)), true
+}
+
+// Persist clears key's expiry, if any, so it is kept indefinitely.
+func (s *KV[T1, T2]) Persist(key T1) error {
+	keyBytes, err := json.Marshal(key)
+	if err != nil {
+		return fmt.Errorf("failed to marshal key: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	query := fmt.Sprintf("UPDATE %s SET expiry = NULL WHERE key = ?", s.table)
+	_, err = s.db.ExecContext(ctx, query, string(keyBytes))
+	return err
+}
+
+// startReaper launches the background goroutine that deletes expired keys
+// and publishes WatchEventDelete for each, stopped by closing s.reaperStop.
+// It runs for the lifetime of the store; there is no public stop hook yet,
+// matching StopAllWatchers being the only such hook today.
+func (s *KV[T1, T2]) startReaper() {
+	s.reaperStop = make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(s.ttlCfg.reaperInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.reaperStop:
+				return
+			case <-ticker.C:
+				s.reapExpired()
+			}
+		}
+	}()
+}
+
+// reapExpired deletes one batch of expired rows and publishes a
+// WatchEventDelete for each row it actually removed.
+func (s *KV[T1, T2]) reapExpired() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := fmt.Sprintf("SELECT key, value FROM %s WHERE expiry IS NOT NULL AND expiry <= ? LIMIT ?", s.table)
+	rows, err := s.db.QueryContext(ctx, query, time.Now().UnixNano(), s.ttlCfg.reaperBatchSize)
+	if err != nil {
+		return
+	}
+
+	type expiredRow struct {
+		keyStr, valueStr string
+	}
+	var expired []expiredRow
+	for rows.Next() {
+		var r expiredRow
+		if err := rows.Scan(&r.keyStr, &r.valueStr); err != nil {
+			continue
+		}
+		expired = append(expired, r)
+	}
+	rows.Close()
+
+	deleteSQL := fmt.Sprintf("DELETE FROM %s WHERE key = ? AND expiry IS NOT NULL AND expiry <= ?", s.table)
+	for _, r := range expired {
+		result, err := s.db.ExecContext(ctx, deleteSQL, r.keyStr, time.Now().UnixNano())
+		if err != nil {
+			continue
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			continue // refreshed or persisted concurrently; don't notify
+		}
+
+		if s.broadcaster == nil {
+			continue
+		}
+		var k T1
+		var v T2
+		if err := json.Unmarshal([]byte(r.keyStr), &k); err != nil {
+			continue
+		}
+		if err := json.Unmarshal([]byte(r.valueStr), &v); err != nil {
+			continue
+		}
+		event := WatchEvent[T1, T2]{
+			Type:      WatchEventDelete,
+			Key:       k,
+			OldValue:  v,
+			Action:    Delete,
+			PrevValue: v,
+			Exists:    true,
+		}
+		var zero T2
+		if rev, histErr := s.appendHistory(k, v, zero, Delete); histErr == nil {
+			event.Rev = rev
+		}
+		s.broadcaster.publish(r.keyStr, event)
+	}
+}
@@ -0,0 +1,94 @@
+package kvstore_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/xtdlib/kvstore"
+)
+
+func TestSetTTLExpiresAndNotifies(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_ttl.db")
+
+	store, err := kvstore.NewAt[string, string](dbPath, "test_ttl", kvstore.WithReaperInterval(50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	if err := store.SetTTL("session:1", "alice", 150*time.Millisecond); err != nil {
+		t.Fatalf("SetTTL failed: %v", err)
+	}
+	if !store.Has("session:1") {
+		t.Fatal("Expected session:1 to exist immediately after SetTTL")
+	}
+
+	eventCh, cancel := store.Watch("session:1")
+	defer cancel()
+
+	select {
+	case event := <-eventCh:
+		if event.Type != kvstore.WatchEventDelete {
+			t.Fatalf("Expected a delete event on expiry, got %+v", event)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("Timeout waiting for TTL expiry to delete session:1")
+	}
+
+	if store.Has("session:1") {
+		t.Fatal("Expected session:1 to be gone after TTL expiry")
+	}
+}
+
+func TestTTLReportsRemainingDuration(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_ttl_remaining.db")
+
+	store, err := kvstore.NewAt[string, string](dbPath, "test_ttl_remaining")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	if _, ok := store.TTL("missing"); ok {
+		t.Fatal("Expected TTL to report ok=false for a key with no TTL")
+	}
+
+	if err := store.SetTTL("key1", "v1", time.Minute); err != nil {
+		t.Fatalf("SetTTL failed: %v", err)
+	}
+
+	ttl, ok := store.TTL("key1")
+	if !ok {
+		t.Fatal("Expected TTL to report ok=true for key1")
+	}
+	if ttl <= 0 || ttl > time.Minute {
+		t.Fatalf("Expected TTL remaining in (0, 1m], got %v", ttl)
+	}
+}
+
+func TestPersistClearsExpiry(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_ttl_persist.db")
+
+	store, err := kvstore.NewAt[string, string](dbPath, "test_ttl_persist", kvstore.WithReaperInterval(50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	if err := store.SetTTL("key1", "v1", 150*time.Millisecond); err != nil {
+		t.Fatalf("SetTTL failed: %v", err)
+	}
+	if err := store.Persist("key1"); err != nil {
+		t.Fatalf("Persist failed: %v", err)
+	}
+
+	if _, ok := store.TTL("key1"); ok {
+		t.Fatal("Expected TTL to report ok=false after Persist")
+	}
+
+	time.Sleep(400 * time.Millisecond)
+	if !store.Has("key1") {
+		t.Fatal("Expected key1 to survive past its original expiry after Persist")
+	}
+}
@@ -0,0 +1,298 @@
+package kvstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+// CompareTarget selects what a Compare predicate inspects.
+type CompareTarget int
+
+const (
+	// ValueEqual holds if key's current value equals Compare.Value.
+	ValueEqual CompareTarget = iota
+	// ValueNotEqual holds if key is absent or its value differs from
+	// Compare.Value.
+	ValueNotEqual
+	// ModRevisionLess holds if key's most recent write revision is less
+	// than Compare.Rev.
+	ModRevisionLess
+	// KeyAbsent holds if key has no current value, matching etcd's
+	// create_revision == 0 idiom for "insert if absent".
+	KeyAbsent
+	// VersionEqual holds if key has been written Compare.Version times
+	// since its last delete (or since creation, if never deleted).
+	VersionEqual
+)
+
+// Compare is one predicate evaluated inside Txn. All of a Txn's Compares
+// are evaluated against the same atomic snapshot.
+type Compare[T1 comparable, T2 comparable] struct {
+	Key     T1
+	Target  CompareTarget
+	Value   T2
+	Rev     int64
+	Version int64
+}
+
+// OpKind selects what an Op does inside Txn.
+type OpKind int
+
+const (
+	OpPut OpKind = iota
+	OpDelete
+	OpGet
+	OpRange
+)
+
+// Op is one action run as part of a Txn's Then or Else branch.
+type Op[T1 comparable, T2 comparable] struct {
+	Kind   OpKind
+	Key    T1
+	Value  T2
+	Lo, Hi T1 // for OpRange
+}
+
+// OpResult reports the outcome of one Op from a TxnResponse.
+type OpResult[T1 comparable, T2 comparable] struct {
+	Kind   OpKind
+	Key    T1
+	Value  T2
+	Exists bool
+	Pairs  []KeyValue[T1, T2] // populated for OpRange
+}
+
+// KeyValue is a key/value pair, as returned by an OpRange result.
+type KeyValue[T1 comparable, T2 comparable] struct {
+	Key   T1
+	Value T2
+}
+
+// TxnResponse reports which branch of a Txn ran and the result of each Op
+// in that branch, in order.
+type TxnResponse[T1 comparable, T2 comparable] struct {
+	Succeeded bool
+	Responses []OpResult[T1, T2]
+}
+
+// TxnOps evaluates ifs atomically inside a single SQL transaction: if every
+// Compare holds, thens run and TxnResponse.Succeeded is true; otherwise
+// elses run and it is false. This gives lock-free optimistic concurrency
+// (increment-if-unchanged, insert-if-absent, compare-and-swap) without a
+// hand-rolled Transaction(func(tx){...}) retry loop. KV.Txn provides a
+// fluent If/Then/Else builder on top of this for the common case where the
+// Compares/Ops are known upfront rather than assembled programmatically.
+//
+// Like DeleteRange/DeletePrefix, Puts and Deletes performed here go
+// through Tx's pendingEvents mechanism: a history entry and watch event
+// are recorded for each, once the whole Txn call has committed.
+func (s *KV[T1, T2]) TxnOps(ctx context.Context, ifs []Compare[T1, T2], thens []Op[T1, T2], elses []Op[T1, T2]) (TxnResponse[T1, T2], error) {
+	var resp TxnResponse[T1, T2]
+
+	if err := s.requireDefaultBackend("TxnOps"); err != nil {
+		return resp, err
+	}
+
+	sqlTx, err := beginImmediate(ctx, s.db)
+	if err != nil {
+		return resp, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	tx := &Tx[T1, T2]{
+		tx:    sqlTx,
+		table: s.table,
+		store: s,
+	}
+
+	resp.Succeeded = true
+	for _, cmp := range ifs {
+		held, err := tx.evalCompare(cmp)
+		if err != nil {
+			sqlTx.Rollback()
+			return resp, err
+		}
+		if !held {
+			resp.Succeeded = false
+			break
+		}
+	}
+
+	ops := thens
+	if !resp.Succeeded {
+		ops = elses
+	}
+
+	for _, op := range ops {
+		result, err := tx.runOp(op)
+		if err != nil {
+			sqlTx.Rollback()
+			return resp, err
+		}
+		resp.Responses = append(resp.Responses, result)
+	}
+
+	if err := sqlTx.Commit(); err != nil {
+		return resp, fmt.Errorf("commit failed: %w", err)
+	}
+
+	// Only now that the transaction is durable do we record history and
+	// notify watchers, so observers never see events for uncommitted work.
+	s.publishPendingEvents(tx)
+
+	return resp, nil
+}
+
+// evalCompare evaluates one Compare predicate against tx's view of the
+// store, which is consistent with every other predicate and Op in the same
+// Txn call.
+func (tx *Tx[T1, T2]) evalCompare(cmp Compare[T1, T2]) (bool, error) {
+	switch cmp.Target {
+	case ValueEqual, ValueNotEqual:
+		val, err := tx.Get(cmp.Key)
+		if err != nil && err != sql.ErrNoRows {
+			return false, err
+		}
+		exists := err == nil
+		equal := exists && val == cmp.Value
+		if cmp.Target == ValueEqual {
+			return equal, nil
+		}
+		return !equal, nil
+
+	case KeyAbsent:
+		exists, err := tx.Has(cmp.Key)
+		if err != nil {
+			return false, err
+		}
+		return !exists, nil
+
+	case ModRevisionLess:
+		modRev, _, err := tx.keyRevision(cmp.Key)
+		if err != nil {
+			return false, err
+		}
+		return modRev < cmp.Rev, nil
+
+	case VersionEqual:
+		_, version, err := tx.keyRevision(cmp.Key)
+		if err != nil {
+			return false, err
+		}
+		return version == cmp.Version, nil
+
+	default:
+		return false, fmt.Errorf("kvstore: unknown CompareTarget %d", cmp.Target)
+	}
+}
+
+// keyRevision returns key's most recent write revision (0 if it has never
+// been written) and its version: the number of writes since its last
+// delete, or since creation if it has never been deleted. It reads the
+// history table through tx's own *sql.Tx, so it sees the same snapshot as
+// every Get/Set/Delete in the same Txn call.
+func (tx *Tx[T1, T2]) keyRevision(key T1) (modRev int64, version int64, err error) {
+	keyBytes, err := json.Marshal(key)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to marshal key: %w", err)
+	}
+
+	query := fmt.Sprintf("SELECT rev, action FROM %s WHERE key = ? ORDER BY rev", tx.store.historyTable())
+	rows, err := tx.tx.Query(query, string(keyBytes))
+	if err != nil {
+		return 0, 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rev int64
+		var action int
+		if err := rows.Scan(&rev, &action); err != nil {
+			return 0, 0, err
+		}
+		if Action(action) == Delete {
+			version = 0
+			continue
+		}
+		version++
+		modRev = rev
+	}
+	return modRev, version, rows.Err()
+}
+
+// runOp executes one Op inside tx and reports its result.
+func (tx *Tx[T1, T2]) runOp(op Op[T1, T2]) (OpResult[T1, T2], error) {
+	result := OpResult[T1, T2]{Kind: op.Kind, Key: op.Key}
+
+	switch op.Kind {
+	case OpPut:
+		if err := tx.Set(op.Key, op.Value); err != nil {
+			return result, err
+		}
+		result.Value = op.Value
+		result.Exists = true
+
+	case OpDelete:
+		if err := tx.Delete(op.Key); err != nil {
+			return result, err
+		}
+
+	case OpGet:
+		val, err := tx.Get(op.Key)
+		if err != nil && err != sql.ErrNoRows {
+			return result, err
+		}
+		result.Value = val
+		result.Exists = err == nil
+
+	case OpRange:
+		pairs, err := tx.rangeQuery(op.Lo, op.Hi)
+		if err != nil {
+			return result, err
+		}
+		result.Pairs = pairs
+
+	default:
+		return result, fmt.Errorf("kvstore: unknown OpKind %d", op.Kind)
+	}
+
+	return result, nil
+}
+
+// rangeQuery returns every key k with lo <= k < hi, in key order, as seen
+// inside tx.
+func (tx *Tx[T1, T2]) rangeQuery(lo, hi T1) ([]KeyValue[T1, T2], error) {
+	loBytes, err := json.Marshal(lo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal lo: %w", err)
+	}
+	hiBytes, err := json.Marshal(hi)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal hi: %w", err)
+	}
+
+	query := fmt.Sprintf("SELECT key, value FROM %s WHERE key >= ? AND key < ? ORDER BY key", tx.table)
+	rows, err := tx.tx.Query(query, string(loBytes), string(hiBytes))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pairs []KeyValue[T1, T2]
+	for rows.Next() {
+		var keyStr, valueStr string
+		if err := rows.Scan(&keyStr, &valueStr); err != nil {
+			return nil, err
+		}
+		var kv KeyValue[T1, T2]
+		if err := json.Unmarshal([]byte(keyStr), &kv.Key); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal key: %w", err)
+		}
+		if err := json.Unmarshal([]byte(valueStr), &kv.Value); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal value: %w", err)
+		}
+		pairs = append(pairs, kv)
+	}
+	return pairs, rows.Err()
+}
@@ -0,0 +1,48 @@
+package kvstore
+
+import "context"
+
+// TxnBuilder accumulates a Txn's If/Then/Else clauses so it can be built up
+// fluently before Commit runs it. Zero value is not usable; get one from
+// KV.Txn.
+type TxnBuilder[T1 comparable, T2 comparable] struct {
+	store *KV[T1, T2]
+	ctx   context.Context
+	ifs   []Compare[T1, T2]
+	thens []Op[T1, T2]
+	elses []Op[T1, T2]
+}
+
+// Txn starts a fluent Compare/Op builder for KV's lower-level TxnOps method:
+//
+//	resp, err := store.Txn().
+//	    If(kvstore.Compare[string, int]{Key: "balance", Target: kvstore.ValueEqual, Value: 100}).
+//	    Then(kvstore.Op[string, int]{Kind: kvstore.OpPut, Key: "balance", Value: 150}).
+//	    Commit(ctx)
+func (s *KV[T1, T2]) Txn() *TxnBuilder[T1, T2] {
+	return &TxnBuilder[T1, T2]{store: s}
+}
+
+// If appends predicates that must all hold for Then to run instead of Else.
+func (b *TxnBuilder[T1, T2]) If(cmp ...Compare[T1, T2]) *TxnBuilder[T1, T2] {
+	b.ifs = append(b.ifs, cmp...)
+	return b
+}
+
+// Then appends ops to run when every If predicate holds.
+func (b *TxnBuilder[T1, T2]) Then(ops ...Op[T1, T2]) *TxnBuilder[T1, T2] {
+	b.thens = append(b.thens, ops...)
+	return b
+}
+
+// Else appends ops to run when any If predicate fails to hold.
+func (b *TxnBuilder[T1, T2]) Else(ops ...Op[T1, T2]) *TxnBuilder[T1, T2] {
+	b.elses = append(b.elses, ops...)
+	return b
+}
+
+// Commit evaluates the built If/Then/Else against the store, the same as
+// calling KV.TxnOps directly.
+func (b *TxnBuilder[T1, T2]) Commit(ctx context.Context) (TxnResponse[T1, T2], error) {
+	return b.store.TxnOps(ctx, b.ifs, b.thens, b.elses)
+}
@@ -0,0 +1,90 @@
+package kvstore_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/xtdlib/kvstore"
+)
+
+func TestTxnBuilderIfThenElse(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_txn_builder.db")
+
+	store, err := kvstore.NewAt[string, int](dbPath, "test_txn_builder")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	store.Set("balance", 100)
+
+	resp, err := store.Txn().
+		If(kvstore.Compare[string, int]{Key: "balance", Target: kvstore.ValueEqual, Value: 100}).
+		Then(kvstore.Op[string, int]{Kind: kvstore.OpPut, Key: "balance", Value: 200}).
+		Else(kvstore.Op[string, int]{Kind: kvstore.OpPut, Key: "balance", Value: -1}).
+		Commit(context.Background())
+	if err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	if !resp.Succeeded {
+		t.Fatalf("Expected the builder's Txn to succeed")
+	}
+	if got := store.Get("balance"); got != 200 {
+		t.Fatalf("Expected balance 200, got %d", got)
+	}
+}
+
+func TestTxCompareAndSwapAndDelete(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_tx_cas.db")
+
+	store, err := kvstore.NewAt[string, string](dbPath, "test_tx_cas")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	store.Set("key1", "v1")
+
+	err = store.Transaction(func(tx *kvstore.Tx[string, string]) error {
+		swapped, err := tx.CompareAndSwap("key1", "v1", "v2")
+		if err != nil {
+			return err
+		}
+		if !swapped {
+			t.Fatal("Expected CompareAndSwap to succeed")
+		}
+
+		swapped, err = tx.CompareAndSwap("key1", "wrong", "v3")
+		if err != nil {
+			return err
+		}
+		if swapped {
+			t.Fatal("Expected CompareAndSwap against stale value to fail")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Transaction failed: %v", err)
+	}
+	if got := store.Get("key1"); got != "v2" {
+		t.Fatalf("Expected key1=v2, got %s", got)
+	}
+
+	err = store.Transaction(func(tx *kvstore.Tx[string, string]) error {
+		deleted, err := tx.CompareAndDelete("key1", "v2")
+		if err != nil {
+			return err
+		}
+		if !deleted {
+			t.Fatal("Expected CompareAndDelete to succeed")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Transaction failed: %v", err)
+	}
+	if store.Has("key1") {
+		t.Fatal("Expected key1 to be gone after CompareAndDelete")
+	}
+}
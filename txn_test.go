@@ -0,0 +1,305 @@
+package kvstore_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/xtdlib/kvstore"
+)
+
+func TestTxnValueEqualThenElse(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_txn.db")
+
+	store, err := kvstore.NewAt[string, int](dbPath, "test_txn")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	store.Set("balance", 100)
+
+	resp, err := store.TxnOps(context.Background(),
+		[]kvstore.Compare[string, int]{
+			{Key: "balance", Target: kvstore.ValueEqual, Value: 100},
+		},
+		[]kvstore.Op[string, int]{
+			{Kind: kvstore.OpPut, Key: "balance", Value: 150},
+		},
+		[]kvstore.Op[string, int]{
+			{Kind: kvstore.OpPut, Key: "balance", Value: -1},
+		},
+	)
+	if err != nil {
+		t.Fatalf("Txn failed: %v", err)
+	}
+	if !resp.Succeeded {
+		t.Fatalf("Expected Txn to succeed")
+	}
+	if got := store.Get("balance"); got != 150 {
+		t.Fatalf("Expected balance 150, got %d", got)
+	}
+
+	// Now the compare no longer holds, so the else branch runs.
+	resp, err = store.TxnOps(context.Background(),
+		[]kvstore.Compare[string, int]{
+			{Key: "balance", Target: kvstore.ValueEqual, Value: 100},
+		},
+		[]kvstore.Op[string, int]{
+			{Kind: kvstore.OpPut, Key: "balance", Value: 150},
+		},
+		[]kvstore.Op[string, int]{
+			{Kind: kvstore.OpPut, Key: "balance", Value: -1},
+		},
+	)
+	if err != nil {
+		t.Fatalf("Txn failed: %v", err)
+	}
+	if resp.Succeeded {
+		t.Fatalf("Expected Txn to fail its compare")
+	}
+	if got := store.Get("balance"); got != -1 {
+		t.Fatalf("Expected balance -1, got %d", got)
+	}
+}
+
+func TestTxnKeyAbsentInsertIfAbsent(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_txn_absent.db")
+
+	store, err := kvstore.NewAt[string, string](dbPath, "test_txn_absent")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	resp, err := store.TxnOps(context.Background(),
+		[]kvstore.Compare[string, string]{
+			{Key: "lock:leader", Target: kvstore.KeyAbsent},
+		},
+		[]kvstore.Op[string, string]{
+			{Kind: kvstore.OpPut, Key: "lock:leader", Value: "node-1"},
+		},
+		nil,
+	)
+	if err != nil || !resp.Succeeded {
+		t.Fatalf("Expected first Txn to succeed, got %+v, err=%v", resp, err)
+	}
+
+	resp, err = store.TxnOps(context.Background(),
+		[]kvstore.Compare[string, string]{
+			{Key: "lock:leader", Target: kvstore.KeyAbsent},
+		},
+		[]kvstore.Op[string, string]{
+			{Kind: kvstore.OpPut, Key: "lock:leader", Value: "node-2"},
+		},
+		[]kvstore.Op[string, string]{
+			{Kind: kvstore.OpGet, Key: "lock:leader"},
+		},
+	)
+	if err != nil {
+		t.Fatalf("Txn failed: %v", err)
+	}
+	if resp.Succeeded {
+		t.Fatalf("Expected second Txn to fail, key already exists")
+	}
+	if len(resp.Responses) != 1 || resp.Responses[0].Value != "node-1" {
+		t.Fatalf("Expected else-branch Get to return node-1, got %+v", resp.Responses)
+	}
+}
+
+func TestTxnModRevisionLess(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_txn_modrev.db")
+
+	store, err := kvstore.NewAt[string, int](dbPath, "test_txn_modrev")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	store.Set("a", 1)
+	_, rev, err := store.GetRev("a", 1<<62)
+	if err != nil {
+		t.Fatalf("GetRev failed: %v", err)
+	}
+
+	// a's mod revision equals rev, so it is not less than rev: the compare
+	// should fail and the else branch should run.
+	resp, err := store.TxnOps(context.Background(),
+		[]kvstore.Compare[string, int]{
+			{Key: "a", Target: kvstore.ModRevisionLess, Rev: rev},
+		},
+		[]kvstore.Op[string, int]{
+			{Kind: kvstore.OpPut, Key: "a", Value: 100},
+		},
+		[]kvstore.Op[string, int]{
+			{Kind: kvstore.OpPut, Key: "a", Value: -1},
+		},
+	)
+	if err != nil {
+		t.Fatalf("Txn failed: %v", err)
+	}
+	if resp.Succeeded {
+		t.Fatalf("Expected ModRevisionLess to fail: a's mod rev %d is not less than %d", rev, rev)
+	}
+	if got := store.Get("a"); got != -1 {
+		t.Fatalf("Expected else branch to run, got %d", got)
+	}
+
+	// The else branch's Put gave a a new mod revision; re-read it so that
+	// rev+1 is greater than a's mod revision and the compare should hold.
+	_, rev, err = store.GetRev("a", 1<<62)
+	if err != nil {
+		t.Fatalf("GetRev failed: %v", err)
+	}
+	resp, err = store.TxnOps(context.Background(),
+		[]kvstore.Compare[string, int]{
+			{Key: "a", Target: kvstore.ModRevisionLess, Rev: rev + 1},
+		},
+		[]kvstore.Op[string, int]{
+			{Kind: kvstore.OpPut, Key: "a", Value: 100},
+		},
+		[]kvstore.Op[string, int]{
+			{Kind: kvstore.OpPut, Key: "a", Value: -1},
+		},
+	)
+	if err != nil {
+		t.Fatalf("Txn failed: %v", err)
+	}
+	if !resp.Succeeded {
+		t.Fatalf("Expected ModRevisionLess to hold for rev %d < %d", rev, rev+1)
+	}
+	if got := store.Get("a"); got != 100 {
+		t.Fatalf("Expected then branch to run, got %d", got)
+	}
+}
+
+func TestTxnVersionEqual(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_txn_version.db")
+
+	store, err := kvstore.NewAt[string, int](dbPath, "test_txn_version")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	store.Set("a", 1) // version 1
+	store.Set("a", 2) // version 2
+
+	resp, err := store.TxnOps(context.Background(),
+		[]kvstore.Compare[string, int]{
+			{Key: "a", Target: kvstore.VersionEqual, Version: 2},
+		},
+		[]kvstore.Op[string, int]{
+			{Kind: kvstore.OpPut, Key: "a", Value: 100},
+		},
+		[]kvstore.Op[string, int]{
+			{Kind: kvstore.OpPut, Key: "a", Value: -1},
+		},
+	)
+	if err != nil {
+		t.Fatalf("Txn failed: %v", err)
+	}
+	if !resp.Succeeded {
+		t.Fatalf("Expected VersionEqual(2) to hold after two writes")
+	}
+	if got := store.Get("a"); got != 100 {
+		t.Fatalf("Expected then branch to run, got %d", got)
+	}
+
+	// Deleting resets the version, so VersionEqual(1) should hold for the
+	// very next write.
+	store.Delete("a")
+	store.Set("a", 1)
+
+	resp, err = store.TxnOps(context.Background(),
+		[]kvstore.Compare[string, int]{
+			{Key: "a", Target: kvstore.VersionEqual, Version: 1},
+		},
+		[]kvstore.Op[string, int]{
+			{Kind: kvstore.OpPut, Key: "a", Value: 200},
+		},
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("Txn failed: %v", err)
+	}
+	if !resp.Succeeded {
+		t.Fatalf("Expected VersionEqual(1) to hold for the write following a delete")
+	}
+	if got := store.Get("a"); got != 200 {
+		t.Fatalf("Expected then branch to run, got %d", got)
+	}
+}
+
+func TestTxnPutPublishesWatchEventAndHistory(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_txn_watch.db")
+
+	store, err := kvstore.NewAt[string, int](dbPath, "test_txn_watch")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	events, cancel := store.Watch("a")
+	defer cancel()
+
+	resp, err := store.TxnOps(context.Background(),
+		nil,
+		[]kvstore.Op[string, int]{
+			{Kind: kvstore.OpPut, Key: "a", Value: 1},
+		},
+		nil,
+	)
+	if err != nil || !resp.Succeeded {
+		t.Fatalf("Txn failed: %v, resp=%+v", err, resp)
+	}
+
+	select {
+	case event := <-events:
+		if event.Type != kvstore.WatchEventSet || event.Value != 1 {
+			t.Fatalf("Expected a Set event for value 1, got %+v", event)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected a watch event for the TxnOps-driven Put")
+	}
+
+	_, rev, err := store.GetRev("a", 1<<62)
+	if err != nil {
+		t.Fatalf("Expected TxnOps Put to be recorded in history, GetRev failed: %v", err)
+	}
+	if rev == 0 {
+		t.Fatalf("Expected a non-zero revision for the TxnOps-driven Put")
+	}
+}
+
+func TestTxnRange(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_txn_range.db")
+
+	store, err := kvstore.NewAt[string, int](dbPath, "test_txn_range")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	store.Set("a", 1)
+	store.Set("b", 2)
+	store.Set("c", 3)
+
+	resp, err := store.TxnOps(context.Background(),
+		nil,
+		[]kvstore.Op[string, int]{
+			{Kind: kvstore.OpRange, Lo: "a", Hi: "c"},
+		},
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("Txn failed: %v", err)
+	}
+	if !resp.Succeeded {
+		t.Fatalf("Expected Txn with no compares to succeed")
+	}
+	if len(resp.Responses) != 1 || len(resp.Responses[0].Pairs) != 2 {
+		t.Fatalf("Expected 2 pairs in range [a,c), got %+v", resp.Responses)
+	}
+}
@@ -0,0 +1,68 @@
+package kvstore_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/xtdlib/kvstore"
+)
+
+func TestWatchAll(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_watch_all.db")
+
+	store, err := kvstore.NewAt[string, string](dbPath, "test_watch_all")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	eventCh, cancel := store.WatchAll(context.Background())
+	defer cancel()
+
+	store.Set("anything", "goes")
+	select {
+	case event := <-eventCh:
+		if event.Key != "anything" || event.Value != "goes" {
+			t.Fatalf("Unexpected event: %+v", event)
+		}
+	case <-time.After(1 * time.Second):
+		t.Fatal("Timeout waiting for event")
+	}
+}
+
+func TestWatchRangeMatchesJSONEncodedIntKeys(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_watch_int_range.db")
+
+	// Int keys have no meaningful "%v"-based prefix/range relationship, so
+	// this exercises WatchRange matching on the same JSON-encoded bytes
+	// the key column itself stores, which is what makes it agree with
+	// Range/RangePrefix's SQL-level "ORDER BY key" comparisons.
+	store, err := kvstore.NewAt[int, string](dbPath, "test_watch_int_range")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	eventCh, cancel := store.WatchRange(context.Background(), 1, 3)
+	defer cancel()
+
+	store.Set(5, "outside")
+	store.Set(2, "inside")
+
+	select {
+	case event := <-eventCh:
+		if event.Key != 2 || event.Value != "inside" {
+			t.Fatalf("Expected key 2 to match the range, got %+v", event)
+		}
+	case <-time.After(1 * time.Second):
+		t.Fatal("Timeout waiting for in-range event")
+	}
+
+	select {
+	case event := <-eventCh:
+		t.Fatalf("Expected no further events (5 is out of range), got %+v", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+}